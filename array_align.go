@@ -0,0 +1,94 @@
+package merge
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// ArrayAlign aligns orig and merge data by their longest common
+// subsequence, so elements inserted in the middle don't shift everything
+// after them. Elements are matched by WithArrayKey keys, or deep equality
+// without a key. Matched elements are merged recursively, elements only
+// in merge data are inserted in place and the ones only in orig are kept.
+func ArrayAlign(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	matches := lcs(ctx.elemKeys(orig), ctx.elemKeys(mergeData))
+	// sentinel match past both ends flushes the tails
+	matches = append(matches, [2]int{len(orig), len(mergeData)})
+
+	out := make([]any, 0, len(orig)+len(mergeData))
+	i, j := 0, 0
+	for _, m := range matches {
+		for ; i < m[0]; i++ {
+			out = append(out, orig[i])
+		}
+		for ; j < m[1]; j++ {
+			path = append(path, strconv.Itoa(len(out)))
+
+			added, keep, err := ctx.addition(path, mergeData[j])
+
+			path = path[:len(path)-1]
+
+			if err != nil {
+				return nil, err
+			}
+			if keep {
+				out = append(out, added)
+			}
+		}
+		if i == len(orig) || j == len(mergeData) {
+			break
+		}
+
+		path = append(path, strconv.Itoa(len(out)))
+
+		merged, err := UseMerger(ctx, next, path, orig[i], mergeData[j])
+
+		path = path[:len(path)-1]
+
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, merged)
+		i, j = i+1, j+1
+	}
+	ctx.prunedOwn(path)
+	return dropRemoved(out), nil
+}
+
+// lcs returns the index pairs of a longest common subsequence of a and b.
+func lcs(a, b []any) [][2]int {
+	eq := make([][]bool, len(a))
+	// n[i][j] is the length of the LCS of a[i:] and b[j:]
+	n := make([][]int, len(a)+1)
+	for i := range n {
+		n[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		eq[i] = make([]bool, len(b))
+		for j := len(b) - 1; j >= 0; j-- {
+			eq[i][j] = reflect.DeepEqual(a[i], b[j])
+			switch {
+			case eq[i][j]:
+				n[i][j] = n[i+1][j+1] + 1
+			case n[i+1][j] >= n[i][j+1]:
+				n[i][j] = n[i+1][j]
+			default:
+				n[i][j] = n[i][j+1]
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case eq[i][j]:
+			pairs = append(pairs, [2]int{i, j})
+			i, j = i+1, j+1
+		case n[i+1][j] >= n[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
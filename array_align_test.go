@@ -0,0 +1,73 @@
+package merge_test
+
+import (
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestArrayAlign(t *testing.T) {
+	align := merge.WithArrayStrategy(merge.ArrayAlign)
+	byName := merge.WithArrayKey(merge.ByField("name"))
+
+	cases := []TestCase{
+		{
+			Name:     "Insert in the middle",
+			Mode:     merge.ModeInsert,
+			Original: []any{"a", "b", "d"},
+			Merge:    []any{"a", "c", "d"},
+			Options:  []merge.Option{align},
+			Expected: []any{"a", "b", "c", "d"},
+		},
+		{
+			Name:     "Additions at both ends",
+			Mode:     merge.ModeInsert,
+			Original: []any{"b", "c"},
+			Merge:    []any{"a", "b", "c", "d"},
+			Options:  []merge.Option{align},
+			Expected: []any{"a", "b", "c", "d"},
+		},
+		{
+			Name:     "No common elements",
+			Mode:     merge.ModeInsert,
+			Original: []any{1, 2},
+			Merge:    []any{3},
+			Options:  []merge.Option{align},
+			Expected: []any{1, 2, 3},
+		},
+		{
+			Name:     "Empty orig",
+			Mode:     merge.ModeInsert,
+			Original: []any{},
+			Merge:    []any{1, 2},
+			Options:  []merge.Option{align},
+			Expected: []any{1, 2},
+		},
+		{
+			Name:     "Matched by key are merged",
+			Mode:     merge.ModeFullReplace,
+			Original: []any{M("name", "a", "port", 1), M("name", "c", "port", 3)},
+			Merge:    []any{M("name", "b", "port", 2), M("name", "c", "port", 30)},
+			Options:  []merge.Option{align, byName},
+			Expected: []any{M("name", "a", "port", 1), M("name", "b", "port", 2), M("name", "c", "port", 30)},
+		},
+		{
+			Name:     "Insert mode keeps matched values",
+			Mode:     merge.ModeInsert,
+			Original: []any{M("name", "a", "port", 1)},
+			Merge:    []any{M("name", "z"), M("name", "a", "port", 2, "tls", true)},
+			Options:  []merge.Option{align, byName},
+			Expected: []any{M("name", "z"), M("name", "a", "port", 1, "tls", true)},
+		},
+		{
+			Name:     "Filters prune insertions only",
+			Mode:     merge.ModeFullReplace,
+			Original: []any{M("name", "x"), M("name", "a", "password", "s")},
+			Merge:    []any{M("name", "y", "password", "p"), M("name", "x"), M("name", "a", "role", "r")},
+			Options:  []merge.Option{align, byName, merge.WithExclude("**.password")},
+			Expected: []any{M("name", "y"), M("name", "x"), M("name", "a", "password", "s", "role", "r")},
+		},
+	}
+
+	TableTest(t, cases)
+}
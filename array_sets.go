@@ -0,0 +1,144 @@
+package merge
+
+import "strconv"
+
+// KeyFunc extracts the identity of an array element: elements with deeply
+// equal keys count as the same element.
+type KeyFunc func(v any) any
+
+// WithArrayKey compares array elements by key instead of by value in the
+// set strategies (ArrayUnion, ArrayIntersect...), e.g. ByField("name").
+func WithArrayKey(fn KeyFunc) Option {
+	return func(o *Options) { o.ArrayKey = fn }
+}
+
+// ByField keys map[string]any elements by the value of field,
+// other elements are their own key. Maps without field are keyed by
+// their whole value, so they only match deeply equal maps.
+func ByField(field string) KeyFunc {
+	return func(v any) any {
+		if m, ok := v.(map[string]any); ok {
+			if k, found := m[field]; found {
+				return k
+			}
+			return wholeMap{m}
+		}
+		return v
+	}
+}
+
+// wholeMap keys a map by itself without matching a field value
+// that happens to be an equal map.
+type wholeMap struct{ m map[string]any }
+
+// ArrayUnion keeps orig and appends the elements of merge data it doesn't
+// have yet, in order.
+func ArrayUnion(ctx *Context, _ Merger, path []string, orig, mergeData []any) ([]any, error) {
+	out := make([]any, len(orig), len(orig)+len(mergeData))
+	copy(out, orig)
+	return ctx.appendMissing(path, out, ctx.elemKeys(orig), mergeData)
+}
+
+// ArrayIntersect keeps the elements of orig merge data has too.
+func ArrayIntersect(ctx *Context, _ Merger, path []string, orig, mergeData []any) ([]any, error) {
+	keys := ctx.elemKeys(mergeData)
+	return ctx.keepElems(path, orig, func(k any) bool { return contains(keys, k) })
+}
+
+// ArrayDifference removes the elements of merge data from orig.
+func ArrayDifference(ctx *Context, _ Merger, path []string, orig, mergeData []any) ([]any, error) {
+	keys := ctx.elemKeys(mergeData)
+	return ctx.keepElems(path, orig, func(k any) bool { return !contains(keys, k) })
+}
+
+// ArraySymmetricDifference keeps the elements found in only one of orig
+// and merge data: the ones of orig first, then the new ones in order.
+func ArraySymmetricDifference(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	out, err := ArrayDifference(ctx, next, path, orig, mergeData)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.appendMissing(path, out, ctx.elemKeys(orig), mergeData)
+}
+
+// appendMissing appends the values whose keys aren't in keys yet.
+func (c *Context) appendMissing(path []string, out, keys, vals []any) ([]any, error) {
+	for _, v := range vals {
+		k := c.elemKey(v)
+		if contains(keys, k) {
+			continue
+		}
+		n := len(out)
+		var err error
+		if out, err = c.appendNew(path, out, v); err != nil {
+			return nil, err
+		}
+		if len(out) > n {
+			keys = append(keys, k)
+		}
+	}
+	return out, nil
+}
+
+// SparseAsArray uses an array strategy for sparse merge data,
+// taking its values in index order and ignoring the indexes themselves.
+// Removal markers are applied to orig first.
+func SparseAsArray(s ArrayStrategy) SparseStrategy {
+	return func(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+		orig, mergeData, err := ctx.applyRemovals(path, orig, mergeData)
+		if err != nil {
+			return nil, err
+		}
+		return s(ctx, next, path, orig, sparseArrayToArray(mergeData))
+	}
+}
+
+// PrimitiveIgnore always keeps orig.
+func PrimitiveIgnore(_ *Context, _ Merger, _ []string, orig, _ any) (any, error) {
+	return orig, nil
+}
+
+func (c *Context) elemKey(v any) any {
+	if c.opts.ArrayKey == nil {
+		return v
+	}
+	return c.opts.ArrayKey(v)
+}
+
+func (c *Context) elemKeys(arr []any) []any {
+	keys := make([]any, len(arr))
+	for i, v := range arr {
+		keys[i] = c.elemKey(v)
+	}
+	return keys
+}
+
+// keepElems returns the elements of orig keep is true for. Elements
+// filters don't allow to remove are kept too.
+func (c *Context) keepElems(path []string, orig []any, keep func(key any) bool) ([]any, error) {
+	out := make([]any, 0, len(orig))
+	for i, v := range orig {
+		if keep(c.elemKey(v)) {
+			out = append(out, v)
+			continue
+		}
+
+		path = append(path, strconv.Itoa(i))
+
+		removable := c.removable(path, v)
+		var err error
+		if !removable {
+			_, err = c.skip(path, v)
+		}
+
+		path = path[:len(path)-1]
+
+		if err != nil {
+			return nil, err
+		}
+		if !removable {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
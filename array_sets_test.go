@@ -0,0 +1,112 @@
+package merge_test
+
+import (
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestArraySetModes(t *testing.T) {
+	users := func(names ...string) []any {
+		out := make([]any, len(names))
+		for i, n := range names {
+			out[i] = M("name", n)
+		}
+		return out
+	}
+
+	cases := []TestCase{
+		{
+			Name:     "Union keeps orig order",
+			Mode:     merge.ModeUnion,
+			Original: []any{"c", "a"},
+			Merge:    []any{"b", "a", "b"},
+			Expected: []any{"c", "a", "b"},
+		},
+		{
+			Name:     "Union nested and new keys",
+			Mode:     merge.ModeUnion,
+			Original: M("roles", []any{"read"}),
+			Merge:    M("roles", []any{"write"}, "groups", []any{"dev"}),
+			Expected: M("roles", []any{"read", "write"}, "groups", []any{"dev"}),
+		},
+		{
+			Name:     "Intersect",
+			Mode:     merge.ModeIntersect,
+			Original: []any{1, 2, 3, 4},
+			Merge:    []any{4, 2, 5},
+			Expected: []any{2, 4},
+		},
+		{
+			Name:     "Difference revokes entries",
+			Mode:     merge.ModeDifference,
+			Original: M("roles", []any{"read", "write", "admin"}, "name", "x"),
+			Merge:    M("roles", []any{"admin"}, "name", "y", "new", 1),
+			Expected: M("roles", []any{"read", "write"}, "name", "x"),
+		},
+		{
+			Name:     "Symmetric difference",
+			Mode:     merge.ModeSymmetricDifference,
+			Original: []any{1, 2, 3},
+			Merge:    []any{3, 4, 4},
+			Expected: []any{1, 2, 4},
+		},
+		{
+			Name:     "Sparse data is taken as values",
+			Mode:     merge.ModeDifference,
+			Original: []any{"a", "b"},
+			Merge:    map[int]any{5: "a"},
+			Expected: []any{"b"},
+		},
+		{
+			Name:     "Union by key",
+			Mode:     merge.ModeUnion,
+			Original: []any{M("name", "a", "role", "user")},
+			Merge:    []any{M("name", "a", "role", "admin"), M("name", "b")},
+			Options:  []merge.Option{merge.WithArrayKey(merge.ByField("name"))},
+			Expected: []any{M("name", "a", "role", "user"), M("name", "b")},
+		},
+		{
+			Name:     "Difference by key",
+			Mode:     merge.ModeDifference,
+			Original: []any{M("name", "a", "role", "user"), M("name", "b")},
+			Merge:    users("a"),
+			Options:  []merge.Option{merge.WithArrayKey(merge.ByField("name"))},
+			Expected: users("b"),
+		},
+		{
+			Name:     "Intersect by key",
+			Mode:     merge.ModeIntersect,
+			Original: users("a", "b", "c"),
+			Merge:    users("c", "a"),
+			Options:  []merge.Option{merge.WithArrayKey(merge.ByField("name"))},
+			Expected: users("a", "c"),
+		},
+		{
+			Name:     "Union by key compares maps without the key whole",
+			Mode:     merge.ModeUnion,
+			Original: []any{M("name", "a"), M("id", 1)},
+			Merge:    []any{M("id", 1), M("id", 2), M("name", "a", "role", "admin")},
+			Options:  []merge.Option{merge.WithArrayKey(merge.ByField("name"))},
+			Expected: []any{M("name", "a"), M("id", 1), M("id", 2)},
+		},
+		{
+			Name:     "Difference by key keeps maps without the key",
+			Mode:     merge.ModeDifference,
+			Original: []any{M("id", 1), M("id", 2), M("name", "a")},
+			Merge:    []any{M("id", 3), M("name", "a")},
+			Options:  []merge.Option{merge.WithArrayKey(merge.ByField("name"))},
+			Expected: []any{M("id", 1), M("id", 2)},
+		},
+		{
+			Name:     "Difference keeps excluded elements",
+			Mode:     merge.ModeDifference,
+			Original: M("admins", []any{M("name", "root", "password", "x"), M("name", "a")}),
+			Merge:    M("admins", users("root", "a")),
+			Options:  []merge.Option{merge.WithArrayKey(merge.ByField("name")), merge.WithExclude("**.password")},
+			Expected: M("admins", []any{M("name", "root", "password", "x")}),
+		},
+	}
+
+	TableTest(t, cases)
+}
@@ -0,0 +1,290 @@
+// Command go-merge merges JSON and YAML configuration files from the shell.
+//
+//	go-merge [flags] base.json [-mode MODE] overlay.yaml [overlay.json:MODE ...]
+//
+// The first file is the base, every next file is merged into it with the
+// mode set by the last -mode flag before it (insert by default), unless the
+// file carries its own "path:mode" suffix or sidecar. "-" reads stdin.
+//
+// Flags:
+//
+//	-mode NAME          merge mode for the following files
+//	-o FILE             write the result to FILE instead of stdout
+//	-format FORMAT      output format: json (indented), json-compact or yaml;
+//	                    defaults to yaml for -o files ending in .yaml or .yml
+//	                    and to json otherwise
+//	-stdin-format NAME  format of stdin: json (default) or yaml
+//	-check              exit with 1 if merging changes the base
+//	-diff               print the changes made to the base
+//
+// With -check or -diff the merged document is only written when -o is set.
+//
+// Files are decoded by extension with the decoders of load.New: .json,
+// .yaml and .yml. Files with other extensions (.toml, ...) are rejected up
+// front, files without one are read as JSON. YAML is read and written in
+// the subset load.DecodeYAML supports; other formats are available to Go
+// programs through load.RegisterDecoder.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/4nd3r5on/go-merge"
+	"github.com/4nd3r5on/go-merge/load"
+)
+
+const (
+	exitOK      = 0
+	exitChanged = 1
+	exitError   = 2
+)
+
+type config struct {
+	layers      []load.Layer
+	out         string
+	format      string
+	stdinFormat string
+	check       bool
+	diff        bool
+}
+
+var errHelp = errors.New("help requested")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	conf, err := parseArgs(args)
+	if err != nil {
+		if errors.Is(err, errHelp) {
+			fmt.Fprintln(stdout, usage)
+			return exitOK
+		}
+		fmt.Fprintf(stderr, "go-merge: %v\n%s\n", err, usage)
+		return exitError
+	}
+
+	res, base, err := mergeLayers(conf, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "go-merge: %v\n", err)
+		return exitError
+	}
+
+	var changes []merge.Change
+	if conf.check || conf.diff {
+		changes = merge.Diff(base, res)
+	}
+	if conf.diff {
+		printDiff(stdout, changes)
+	}
+
+	if conf.out != "" || !(conf.check || conf.diff) {
+		if err := writeResult(conf, res, stdout); err != nil {
+			fmt.Fprintf(stderr, "go-merge: %v\n", err)
+			return exitError
+		}
+	}
+
+	if conf.check && len(changes) > 0 {
+		return exitChanged
+	}
+	return exitOK
+}
+
+// inputFormats are the formats load.New decodes.
+var inputFormats = []string{"json", "yaml", "yml"}
+
+const usage = `usage: go-merge [-o FILE] [-format json|json-compact|yaml] [-stdin-format json|yaml] [-check] [-diff]
+                base [-mode MODE] overlay[:MODE] ...`
+
+func parseArgs(args []string) (config, error) {
+	conf := config{stdinFormat: "json"}
+	mode := merge.DefaultMergeMode
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-" || strings.HasPrefix(arg, "-:") || !strings.HasPrefix(arg, "-") {
+			layer, err := load.ParseLayerMode(arg, mode)
+			if err != nil {
+				return config{}, err
+			}
+			conf.layers = append(conf.layers, layer)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		takeValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("flag -%s needs a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		var err error
+		switch name {
+		case "mode":
+			var modeName string
+			if modeName, err = takeValue(); err == nil {
+				m, found := merge.ParseMode(modeName)
+				if !found {
+					return config{}, fmt.Errorf("unknown merge mode %q", modeName)
+				}
+				mode = m
+			}
+		case "o", "output":
+			conf.out, err = takeValue()
+		case "format":
+			conf.format, err = takeValue()
+		case "stdin-format":
+			conf.stdinFormat, err = takeValue()
+		case "check":
+			conf.check = true
+		case "diff":
+			conf.diff = true
+		case "h", "help":
+			return config{}, errHelp
+		default:
+			return config{}, fmt.Errorf("unknown flag %s", arg)
+		}
+		if err != nil {
+			return config{}, err
+		}
+	}
+
+	if len(conf.layers) == 0 {
+		return config{}, errors.New("no input files")
+	}
+	if conf.format == "" {
+		conf.format = "json"
+		switch strings.ToLower(filepath.Ext(conf.out)) {
+		case ".yaml", ".yml":
+			conf.format = "yaml"
+		}
+	}
+	switch conf.format {
+	case "json", "json-compact", "yaml":
+	default:
+		return config{}, fmt.Errorf("unknown output format %q", conf.format)
+	}
+	for i, layer := range conf.layers {
+		format := conf.stdinFormat
+		if layer.Path != "-" {
+			format = strings.TrimPrefix(filepath.Ext(layer.Path), ".")
+		}
+		switch format = strings.ToLower(format); {
+		case format == "":
+			conf.layers[i].Format = "json"
+		case !slices.Contains(inputFormats, format):
+			return config{}, fmt.Errorf("%s: unsupported input format %q, expected one of %s",
+				layer.Path, format, strings.Join(inputFormats, ", "))
+		}
+	}
+	return conf, nil
+}
+
+// mergeLayers returns the merged tree and a pristine copy of the base,
+// merging modifies maps of the base in place.
+func mergeLayers(conf config, stdin io.Reader) (res, base any, err error) {
+	stdinData, err := io.ReadAll(readStdin(conf, stdin))
+	if err != nil {
+		return nil, nil, fmt.Errorf("stdin: %w", err)
+	}
+
+	l := load.New()
+	layers := make([]load.Layer, len(conf.layers))
+	for i, layer := range conf.layers {
+		if layer.Path == "-" {
+			layer.Format = conf.stdinFormat
+		}
+		layers[i] = layer
+	}
+
+	decodeBase := func() (any, error) {
+		l.Stdin = bytes.NewReader(stdinData)
+		return l.Decode(layers[0])
+	}
+
+	if base, err = decodeBase(); err != nil {
+		return nil, nil, err
+	}
+	// stdin may be both base and an overlay, rewind it for the loader
+	l.Stdin = bytes.NewReader(stdinData)
+	if res, err = l.Load(base, layers[1:]...); err != nil {
+		return nil, nil, err
+	}
+
+	if base, err = decodeBase(); err != nil {
+		return nil, nil, err
+	}
+	return res, base, nil
+}
+
+func readStdin(conf config, stdin io.Reader) io.Reader {
+	for _, layer := range conf.layers {
+		if layer.Path == "-" {
+			return stdin
+		}
+	}
+	return strings.NewReader("")
+}
+
+func writeResult(conf config, res any, stdout io.Writer) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch conf.format {
+	case "yaml":
+		data, err = encodeYAML(res)
+	case "json-compact":
+		data, err = json.Marshal(res)
+	default:
+		data, err = json.MarshalIndent(res, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	if conf.format != "yaml" {
+		data = append(data, '\n')
+	}
+
+	if conf.out == "" {
+		_, err = stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(conf.out, data, 0o644)
+}
+
+func printDiff(w io.Writer, changes []merge.Change) {
+	for _, c := range changes {
+		switch c.Kind {
+		case merge.Added:
+			fmt.Fprintf(w, "+ %s: %s\n", c.Path, compact(c.New))
+		case merge.Removed:
+			fmt.Fprintf(w, "- %s: %s\n", c.Path, compact(c.Old))
+		default:
+			fmt.Fprintf(w, "~ %s: %s -> %s\n", c.Path, compact(c.Old), compact(c.New))
+		}
+	}
+}
+
+func compact(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
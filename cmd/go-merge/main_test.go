@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge/load"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func runCmd(stdin string, args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = run(args, strings.NewReader(stdin), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "base.json", `{"db": {"host": "a", "port": 1}, "tags": ["x"]}`)
+	upd := writeFile(t, dir, "upd.json", `{"db": {"port": 2, "user": "u"}}`)
+	app := writeFile(t, dir, "app.json", `{"tags": ["y"]}`)
+	same := writeFile(t, dir, "same.json", `{"db": {"host": "a"}}`)
+	noExt := writeFile(t, dir, "overlay", `{"db": {"host": "b"}}`)
+	null := writeFile(t, dir, "null.json", `null`)
+	arr := writeFile(t, dir, "arr.json", `[1, 2]`)
+	yamlApp := writeFile(t, dir, "app.yaml", "tags:\n  - y # from yaml\n")
+
+	cases := []struct {
+		name  string
+		stdin string
+		args  []string
+		code  int
+		out   string
+	}{
+		{
+			name: "modes apply to following files",
+			args: []string{"-format", "json-compact", "-mode", "update", base, upd, "-mode=append", app},
+			out:  `{"db":{"host":"a","port":2},"tags":["x","y"]}` + "\n",
+		},
+		{
+			name: "suffix overrides mode flag",
+			args: []string{"-format=json-compact", "-mode", "update", base, upd + ":insert"},
+			out:  `{"db":{"host":"a","port":1,"user":"u"},"tags":["x"]}` + "\n",
+		},
+		{
+			name:  "stdin overlay",
+			stdin: `{"tags": ["z"]}`,
+			args:  []string{"-format", "json-compact", base, "-:append"},
+			out:   `{"db":{"host":"a","port":1},"tags":["x","z"]}` + "\n",
+		},
+		{
+			name: "check without changes",
+			args: []string{"--check", "-mode", "update", base, same},
+			code: exitOK,
+		},
+		{
+			name: "check with changes",
+			args: []string{"--check", "-mode", "update", base, upd},
+			code: exitChanged,
+		},
+		{
+			name: "diff",
+			args: []string{"--diff", base, upd, app + ":append"},
+			out:  "+ db.user: \"u\"\n+ tags.1: \"y\"\n",
+		},
+		{
+			name: "unknown mode",
+			args: []string{"-mode", "nope", base},
+			code: exitError,
+		},
+		{
+			name: "yaml overlay",
+			args: []string{"-format", "json-compact", "-mode", "update", base, upd, "-mode", "append", yamlApp},
+			out:  `{"db":{"host":"a","port":2},"tags":["x","y"]}` + "\n",
+		},
+		{
+			name:  "yaml stdin and output",
+			stdin: "db: {port: 3}\n",
+			args:  []string{"-format", "yaml", "-stdin-format", "yaml", base, "-:update"},
+			out:   "db:\n  host: a\n  port: 3\ntags:\n  - x\n",
+		},
+		{
+			name: "unsupported file format",
+			args: []string{base, "overlay.toml"},
+			code: exitError,
+		},
+		{
+			name: "unsupported stdin format",
+			args: []string{"-stdin-format", "toml", base, "-"},
+			code: exitError,
+		},
+		{
+			name: "unknown output format",
+			args: []string{"-format", "toml", base},
+			code: exitError,
+		},
+		{
+			name: "file without extension read as json",
+			args: []string{"-format", "json-compact", base, noExt + ":update"},
+			out:  `{"db":{"host":"b","port":1},"tags":["x"]}` + "\n",
+		},
+		{
+			name: "null base keeps the overlay's mode",
+			args: []string{"-format", "json-compact", null, arr + ":difference"},
+			out:  "null\n",
+		},
+		{
+			name: "no files",
+			args: []string{"-check"},
+			code: exitError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, out, errOut := runCmd(tc.stdin, tc.args...)
+			if tc.code == exitError && !strings.Contains(errOut, "go-merge:") {
+				t.Errorf("expected an error message, got %q", errOut)
+			}
+			if code != tc.code {
+				t.Fatalf("exit code %d, expected %d, stderr: %s", code, tc.code, errOut)
+			}
+			if tc.out != "" && out != tc.out {
+				t.Errorf("stdout:\n%s\nexpected:\n%s", out, tc.out)
+			}
+		})
+	}
+}
+
+func TestRun_OutputFile(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "base.json", `{"a": 1}`)
+	out := filepath.Join(dir, "out.json")
+
+	code, stdout, errOut := runCmd("", base, "-o", out)
+	if code != exitOK {
+		t.Fatalf("exit code %d, stderr: %s", code, errOut)
+	}
+	if stdout != "" {
+		t.Errorf("expected nothing on stdout, got %q", stdout)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{\n  \"a\": 1\n}\n" {
+		t.Errorf("unexpected output file: %q", data)
+	}
+}
+
+func TestRun_OutputFileFormat(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "base.json", `{"a": 1}`)
+	out := filepath.Join(dir, "out.yml")
+
+	if code, _, errOut := runCmd("", base, "-o", out); code != exitOK {
+		t.Fatalf("exit code %d, stderr: %s", code, errOut)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a: 1\n" {
+		t.Errorf("expected yaml picked from the extension, got %q", data)
+	}
+}
+
+func TestEncodeYAML(t *testing.T) {
+	in := map[string]any{
+		"str":    "plain",
+		"quoted": []any{"true", "1.5", "a: b", "x # y", "", " pad", "multi\nline", "- dash", "null"},
+		"nested": []any{map[string]any{"a": float64(1), "b": []any{}}, []any{false, nil}, map[string]any{}},
+		"a: key": map[string]any{"deep": map[string]any{"x": 2.5}},
+		"empty":  map[string]any{},
+	}
+	data, err := encodeYAML(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"a: key":
+  deep:
+    x: 2.5
+empty: {}
+nested:
+  - a: 1
+    b: []
+  - - false
+    - null
+  - {}
+quoted:
+  - "true"
+  - "1.5"
+  - "a: b"
+  - "x # y"
+  - ""
+  - " pad"
+  - "multi\nline"
+  - "- dash"
+  - "null"
+str: plain
+`
+	if string(data) != want {
+		t.Errorf("got:\n%s\nexpected:\n%s", data, want)
+	}
+
+	got, err := load.DecodeYAML(data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !reflect.DeepEqual(got, in) {
+		t.Errorf("round trip: got %v, expected %v", got, in)
+	}
+}
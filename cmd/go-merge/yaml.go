@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/4nd3r5on/go-merge/load"
+)
+
+// encodeYAML writes v in block style with sorted keys, the output reads
+// back with load.DecodeYAML.
+func encodeYAML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeYAML(&buf, v, 0, false); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeYAML writes v and a trailing newline. Lines of nested blocks are
+// indented by indent, except the first one when inline is set: it follows
+// the "- " of a sequence item already written.
+func writeYAML(buf *bytes.Buffer, v any, indent int, inline bool) error {
+	pad := func(first bool) {
+		if !first || !inline {
+			buf.WriteString(strings.Repeat(" ", indent))
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		if len(val) == 0 {
+			break
+		}
+		for i, k := range slices.Sorted(maps.Keys(val)) {
+			pad(i == 0)
+			buf.WriteString(yamlString(k))
+			buf.WriteByte(':')
+			if err := writeYAMLValue(buf, val[k], indent+2); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		if len(val) == 0 {
+			break
+		}
+		for i, item := range val {
+			pad(i == 0)
+			buf.WriteByte('-')
+			if isYAMLBlock(item) {
+				buf.WriteByte(' ')
+				if err := writeYAML(buf, item, indent+2, true); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := writeYAMLValue(buf, item, indent+2); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	s, err := yamlScalar(v)
+	if err != nil {
+		return err
+	}
+	pad(true)
+	buf.WriteString(s)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// writeYAMLValue writes the value of a key or sequence item after its
+// ':' or '-', nested blocks start on the next line.
+func writeYAMLValue(buf *bytes.Buffer, v any, indent int) error {
+	if isYAMLBlock(v) {
+		buf.WriteByte('\n')
+		return writeYAML(buf, v, indent, false)
+	}
+	buf.WriteByte(' ')
+	return writeYAML(buf, v, 0, false)
+}
+
+func isYAMLBlock(v any) bool {
+	switch val := v.(type) {
+	case map[string]any:
+		return len(val) > 0
+	case []any:
+		return len(val) > 0
+	}
+	return false
+}
+
+func yamlScalar(v any) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return yamlString(val), nil
+	case map[string]any:
+		return "{}", nil
+	case []any:
+		return "[]", nil
+	case float64:
+		switch {
+		case math.IsInf(val, 1):
+			return ".inf", nil
+		case math.IsInf(val, -1):
+			return "-.inf", nil
+		case math.IsNaN(val):
+			return ".nan", nil
+		}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encode: %w", err)
+	}
+	switch data[0] {
+	case '{', '[':
+		return "", fmt.Errorf("encode: unsupported type %T", v)
+	}
+	return string(data), nil
+}
+
+// yamlString leaves s plain when it reads back as the same string,
+// otherwise it's double quoted.
+func yamlString(s string) string {
+	if s != "" && !strings.ContainsAny(s, "\n\r\t") {
+		if v, err := load.DecodeYAML([]byte(s)); err == nil && v == s {
+			return s
+		}
+	}
+	return strconv.Quote(s)
+}
@@ -0,0 +1,163 @@
+package merge
+
+// MapStrategy merges maps of every key kind the same way.
+type MapStrategy interface {
+	MergeMap(ctx *Context, next Merger, path []string, orig, mergeData map[string]any) (map[string]any, error)
+	MergeIntMap(ctx *Context, next Merger, path []string, orig, mergeData map[int]any) (map[int]any, error)
+	MergeAnyMap(ctx *Context, next Merger, path []string, orig, mergeData map[any]any) (map[any]any, error)
+}
+
+type ArrayStrategy func(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error)
+
+type SparseStrategy func(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error)
+
+type PrimitiveStrategy func(ctx *Context, next Merger, path []string, orig, mergeData any) (any, error)
+
+// ComposedMerger is a Merger built from a strategy per kind of data.
+// Nil strategies fall back to the ones of ModeInsert.
+type ComposedMerger struct {
+	Maps       MapStrategy
+	Arrays     ArrayStrategy
+	Sparse     SparseStrategy
+	IntMaps    MapStrategy
+	Primitives PrimitiveStrategy
+}
+
+// Compose builds a Merger out of strategies, e.g. insert with unique arrays:
+//
+//	merge.Compose(merge.MapInsert, merge.ArrayUnique, merge.SparseInsert, merge.MapInsert, merge.PrimitiveKeep)
+//
+// intMaps is used for map[int]any and maps with other non-string keys.
+func Compose(
+	maps MapStrategy,
+	arrays ArrayStrategy,
+	sparse SparseStrategy,
+	intMaps MapStrategy,
+	primitives PrimitiveStrategy,
+) *ComposedMerger {
+	return &ComposedMerger{
+		Maps:       maps,
+		Arrays:     arrays,
+		Sparse:     sparse,
+		IntMaps:    intMaps,
+		Primitives: primitives,
+	}
+}
+
+func (m *ComposedMerger) MergeMap(ctx *Context, next Merger, path []string, orig, mergeData map[string]any) (map[string]any, error) {
+	return orMap(m.Maps).MergeMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *ComposedMerger) MergeArray(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	if m.Arrays == nil {
+		return ArrayInsert(ctx, next, path, orig, mergeData)
+	}
+	return m.Arrays(ctx, next, path, orig, mergeData)
+}
+
+func (m *ComposedMerger) MergeSparseArray(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	if m.Sparse == nil {
+		return SparseInsert(ctx, next, path, orig, mergeData)
+	}
+	return m.Sparse(ctx, next, path, orig, mergeData)
+}
+
+func (m *ComposedMerger) MergeIntMap(ctx *Context, next Merger, path []string, orig, mergeData map[int]any) (map[int]any, error) {
+	return orMap(m.IntMaps).MergeIntMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *ComposedMerger) MergeAnyMap(ctx *Context, next Merger, path []string, orig, mergeData map[any]any) (map[any]any, error) {
+	return orMap(m.IntMaps).MergeAnyMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *ComposedMerger) MergePrimitive(ctx *Context, next Merger, path []string, orig, mergeData any) (any, error) {
+	if m.Primitives == nil {
+		return PrimitiveKeep(ctx, next, path, orig, mergeData)
+	}
+	return m.Primitives(ctx, next, path, orig, mergeData)
+}
+
+func orMap(s MapStrategy) MapStrategy {
+	if s == nil {
+		return MapInsert
+	}
+	return s
+}
+
+// keyMerge merges maps key by key: existing keys are merged recursively,
+// missing ones are added only with addMissing.
+type keyMerge struct {
+	addMissing bool
+}
+
+var (
+	// MapInsert adds missing keys and merges existing ones recursively.
+	MapInsert MapStrategy = keyMerge{addMissing: true}
+	// MapUpdate merges existing keys recursively and ignores missing ones.
+	MapUpdate MapStrategy = keyMerge{addMissing: false}
+)
+
+func (s keyMerge) MergeMap(ctx *Context, next Merger, path []string, orig, mergeData map[string]any) (map[string]any, error) {
+	return mergeMapKeys(ctx, next, path, orig, mergeData, s.addMissing)
+}
+
+func (s keyMerge) MergeIntMap(ctx *Context, next Merger, path []string, orig, mergeData map[int]any) (map[int]any, error) {
+	return mergeMapKeys(ctx, next, path, orig, mergeData, s.addMissing)
+}
+
+func (s keyMerge) MergeAnyMap(ctx *Context, next Merger, path []string, orig, mergeData map[any]any) (map[any]any, error) {
+	return mergeMapKeys(ctx, next, path, orig, mergeData, s.addMissing)
+}
+
+func mergeMapKeys[K comparable](
+	ctx *Context,
+	next Merger,
+	path []string,
+	orig, mergeData map[K]any,
+	addMissing bool,
+) (map[K]any, error) {
+	if orig == nil {
+		if !addMissing || mergeData == nil {
+			return orig, nil
+		}
+		orig = make(map[K]any, len(mergeData))
+	}
+
+	for k, v := range mergeData {
+		old, exists := orig[k]
+		if !exists {
+			if !addMissing {
+				continue
+			}
+
+			path = append(path, pathSegment(k))
+
+			added, keep, err := ctx.addition(path, v)
+
+			path = path[:len(path)-1]
+
+			if err != nil {
+				return nil, err
+			}
+			if keep {
+				orig[k] = added
+			}
+			continue
+		}
+
+		// mutate path
+		path = append(path, pathSegment(k))
+
+		merged, err := UseMerger(ctx, next, path, old, v)
+
+		// restore
+		path = path[:len(path)-1]
+
+		if err != nil {
+			return nil, err
+		}
+
+		storeKey(orig, k, merged)
+	}
+	return orig, nil
+}
@@ -0,0 +1,91 @@
+package merge_test
+
+import (
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestCompose(t *testing.T) {
+	e := merge.NewEngine()
+
+	insertUnique, err := e.RegisterMode("insert_u", merge.Compose(
+		merge.MapInsert, merge.ArrayUnique, merge.SparseInsert, merge.MapInsert, merge.PrimitiveKeep,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	updateReplaceArrays, err := e.RegisterMode("update_r", merge.Compose(
+		merge.MapUpdate, merge.ArrayReplace, merge.SparseUpdate, merge.MapUpdate, merge.PrimitiveReplace,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults, err := e.RegisterMode("defaults", &merge.ComposedMerger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name           string
+		mode           merge.Mode
+		orig, data, ex any
+	}{
+		{
+			name: "insert with unique arrays",
+			mode: insertUnique,
+			orig: M("a", 1, "tags", []any{"x", "y"}),
+			data: M("a", 2, "b", 3, "tags", []any{"y", "z"}),
+			ex:   M("a", 1, "b", 3, "tags", []any{"x", "y", "z"}),
+		},
+		{
+			name: "update with whole array replacement",
+			mode: updateReplaceArrays,
+			orig: M("a", 0, "tags", []any{"x", "y"}),
+			data: M("a", 2, "b", 3, "tags", []any{"z"}),
+			ex:   M("a", 2, "tags", []any{"z"}),
+		},
+		{
+			name: "nil strategies behave like insert",
+			mode: defaults,
+			orig: M("a", 1, "ids", map[int]any{1: "x"}),
+			data: M("a", 2, "b", 3, "ids", map[int]any{2: "y"}),
+			ex:   M("a", 1, "b", 3, "ids", map[int]any{1: "x", 2: "y"}),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := e.Data(tc.mode, tc.orig, tc.data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if toJSON(res) != toJSON(tc.ex) {
+				t.Errorf("Result mismatch:\nGot:      %s\nExpected: %s", toJSON(res), toJSON(tc.ex))
+			}
+		})
+	}
+}
+
+func TestWithArrayStrategy(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "insert with unique arrays",
+			Mode:     merge.ModeInsert,
+			Original: M("tags", []any{"x", "y"}),
+			Merge:    M("tags", []any{"y", "z"}),
+			Options:  []merge.Option{merge.WithArrayStrategy(merge.ArrayUnique)},
+			Expected: M("tags", []any{"x", "y", "z"}),
+		},
+		{
+			Name:     "update with appended arrays",
+			Mode:     merge.ModeUpdate,
+			Original: M("tags", []any{"x", "y"}),
+			Merge:    M("tags", []any{"y"}),
+			Options:  []merge.Option{merge.WithArrayStrategy(merge.ArrayAppend)},
+			Expected: M("tags", []any{"x", "y", "y"}),
+		},
+	}
+
+	TableTest(t, cases)
+}
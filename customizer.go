@@ -0,0 +1,77 @@
+package merge
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Customizer is consulted for every node before the mode's own logic,
+// like lodash mergeWith. Returning handled=false falls back to the mode.
+// For map keys missing in orig and array elements merge data appends
+// it's called with a nil orig.
+type Customizer func(path Path, orig, incoming any) (result any, handled bool, err error)
+
+// WithCustomizer consults fn at every node of the merge.
+func WithCustomizer(fn Customizer) Option {
+	return func(o *Options) { o.Customizer = fn }
+}
+
+// With merges data into orig consulting fn at every node first:
+//
+//	res, err := merge.With(merge.ModeFullReplace, orig, data, func(p merge.Path, orig, incoming any) (any, bool, error) {
+//		if p.String() == "spec.replicas" {
+//			return max(orig.(int), incoming.(int)), true, nil
+//		}
+//		return nil, false, nil
+//	})
+func With(mode Mode, orig, data any, fn Customizer, opts ...Option) (any, error) {
+	return Data(mode, orig, data, append(opts[:len(opts):len(opts)], WithCustomizer(fn))...)
+}
+
+// customize runs the customizer for a node if there is one.
+func (c *Context) customize(path []string, orig, incoming any) (any, bool, error) {
+	if c.opts.Customizer == nil {
+		return nil, false, nil
+	}
+	res, handled, err := c.opts.Customizer(append(Path(nil), path...), orig, incoming)
+	if err != nil {
+		return nil, false, fmt.Errorf("customizer failed at %v: %w", formatPath(path), err)
+	}
+	return res, handled, nil
+}
+
+// addition decides what a merge data value without a counterpart
+// in orig turns into, keep is false when nothing is added.
+func (c *Context) addition(path []string, v any) (any, bool, error) {
+	v, keep, err := c.prune(path, v)
+	if err != nil || !keep {
+		return nil, false, err
+	}
+
+	res, handled, err := c.customize(path, nil, v)
+	if err != nil {
+		return nil, false, err
+	}
+	if handled {
+		return res, res != removed, nil
+	}
+	return v, c.keepNew(v), nil
+}
+
+// appendNew appends merge data values without a counterpart in orig to
+// out, consulting the customizer at the index each one ends up at.
+func (c *Context) appendNew(path []string, out []any, vals ...any) ([]any, error) {
+	for _, v := range vals {
+		res, handled, err := c.customize(append(path, strconv.Itoa(len(out))), nil, v)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case handled && res != removed:
+			out = append(out, res)
+		case !handled && c.keepNew(v):
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,113 @@
+package merge_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestWith(t *testing.T) {
+	var seen []string
+	customizer := func(p merge.Path, orig, incoming any) (any, bool, error) {
+		seen = append(seen, p.String())
+		switch p.String() {
+		case "description":
+			if orig == nil {
+				return "[new] " + incoming.(string), true, nil
+			}
+			return orig.(string) + " " + incoming.(string), true, nil
+		case "spec.replicas":
+			return max(orig.(int), incoming.(int)), true, nil
+		}
+		return nil, false, nil
+	}
+
+	orig := M("spec", M("replicas", 5, "image", "app:1"))
+	data := M("spec", M("replicas", 3, "image", "app:2"), "description", "web")
+
+	res, err := merge.With(merge.ModeFullReplace, orig, data, customizer)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := M("spec", M("replicas", 5, "image", "app:2"), "description", "[new] web")
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("Result mismatch:\nGot:      %s\nExpected: %s", toJSON(res), toJSON(want))
+	}
+
+	res, err = merge.With(merge.ModeFullReplace, res, M("description", "server"), customizer)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := res.(map[string]any)["description"]; d != "[new] web server" {
+		t.Errorf("expected concatenated description, got %v", d)
+	}
+
+	for _, p := range []string{"root", "spec", "spec.image", "spec.replicas", "description"} {
+		if !strings.Contains(strings.Join(seen, ","), p) {
+			t.Errorf("customizer not consulted for %s: %v", p, seen)
+		}
+	}
+}
+
+func TestWith_Error(t *testing.T) {
+	errForbidden := errors.New("forbidden")
+	_, err := merge.With(merge.ModeInsert, M("a", M("b", 1)), M("a", M("b", 2)),
+		func(p merge.Path, orig, incoming any) (any, bool, error) {
+			if p.String() == "a.b" {
+				return nil, false, errForbidden
+			}
+			return nil, false, nil
+		})
+	if !errors.Is(err, errForbidden) || !strings.Contains(err.Error(), "[a b]") {
+		t.Errorf("expected wrapped customizer error with path, got %v", err)
+	}
+}
+
+func TestWith_AppendedElements(t *testing.T) {
+	upper := func(p merge.Path, orig, incoming any) (any, bool, error) {
+		if s, ok := incoming.(string); ok && orig == nil {
+			return strings.ToUpper(s), true, nil
+		}
+		return nil, false, nil
+	}
+
+	cases := []struct {
+		mode      merge.Mode
+		orig, add any
+		want      any
+	}{
+		{merge.ModeAppend, []any{"a"}, []any{"b"}, []any{"a", "B"}},
+		{merge.ModeInsert, []any{"a"}, []any{"x", "c"}, []any{"a", "X", "C"}},
+		{merge.ModeFullReplace, []any{"a"}, []any{"b", "c"}, []any{"b", "C"}},
+		{merge.ModeFullReplace, []any{"a"}, map[int]any{1: "c"}, []any{"a", "C"}},
+	}
+	for _, tc := range cases {
+		res, err := merge.With(tc.mode, tc.orig, tc.add, upper)
+		if err != nil {
+			t.Fatalf("mode %d: unexpected error: %v", tc.mode, err)
+		}
+		if !reflect.DeepEqual(res, tc.want) {
+			t.Errorf("mode %d: got %v, expected %v", tc.mode, res, tc.want)
+		}
+	}
+}
+
+func TestWith_KeepsCallerOptions(t *testing.T) {
+	opts := make([]merge.Option, 1, 2)
+	opts[0] = merge.WithStrict()
+	spare := opts[:2]
+	spare[1] = merge.WithCopy()
+
+	keep := func(merge.Path, any, any) (any, bool, error) { return nil, false, nil }
+	if _, err := merge.With(merge.ModeInsert, M(), M("a", 1), keep, opts...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var o merge.Options
+	spare[1](&o)
+	if !o.Copy || o.Customizer != nil {
+		t.Error("With wrote into the caller's options")
+	}
+}
@@ -0,0 +1,140 @@
+package merge
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path addresses a node of a merge tree, one segment per map key or index.
+type Path []string
+
+func (p Path) String() string {
+	if len(p) == 0 {
+		return "root"
+	}
+	return strings.Join(p, ".")
+}
+
+type ChangeKind int
+
+const (
+	Added ChangeKind = iota
+	Modified
+	Removed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Modified:
+		return "modified"
+	case Removed:
+		return "removed"
+	}
+	return "ChangeKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Change is a single difference reported by Diff.
+// Old is nil for Added, New is nil for Removed.
+type Change struct {
+	Path Path
+	Kind ChangeKind
+	Old  any
+	New  any
+}
+
+// Diff compares two merge trees and returns the changes turning old into
+// new, ordered by path. Containers of the same kind are compared element by
+// element, anything else is compared with reflect.DeepEqual.
+func Diff(old, new any) []Change {
+	var changes []Change
+	diff(nil, old, new, &changes)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return lessPath(changes[i].Path, changes[j].Path)
+	})
+	return changes
+}
+
+func diff(path Path, old, new any, changes *[]Change) {
+	switch o := old.(type) {
+	case map[string]any:
+		if n, ok := new.(map[string]any); ok {
+			diffMap(path, o, n, changes)
+			return
+		}
+	case map[int]any:
+		if n, ok := new.(map[int]any); ok {
+			diffMap(path, o, n, changes)
+			return
+		}
+	case []any:
+		if n, ok := new.([]any); ok {
+			for i := 0; i < max(len(o), len(n)); i++ {
+				p := appendPath(path, strconv.Itoa(i))
+				switch {
+				case i >= len(n):
+					*changes = append(*changes, Change{Path: p, Kind: Removed, Old: o[i]})
+				case i >= len(o):
+					*changes = append(*changes, Change{Path: p, Kind: Added, New: n[i]})
+				default:
+					diff(p, o[i], n[i], changes)
+				}
+			}
+			return
+		}
+	default:
+		ov, nv := reflect.ValueOf(old), reflect.ValueOf(new)
+		if ov.Kind() == reflect.Map && nv.IsValid() && ov.Type() == nv.Type() {
+			diffMap(path, toAnyMap(ov), toAnyMap(nv), changes)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, Change{Path: path, Kind: Modified, Old: old, New: new})
+	}
+}
+
+func diffMap[K comparable](path Path, old, new map[K]any, changes *[]Change) {
+	for k, ov := range old {
+		p := appendPath(path, pathSegment(k))
+		nv, exists := new[k]
+		if !exists {
+			*changes = append(*changes, Change{Path: p, Kind: Removed, Old: ov})
+			continue
+		}
+		diff(p, ov, nv, changes)
+	}
+	for k, nv := range new {
+		if _, exists := old[k]; !exists {
+			*changes = append(*changes, Change{Path: appendPath(path, pathSegment(k)), Kind: Added, New: nv})
+		}
+	}
+}
+
+// appendPath never shares the backing array with path,
+// so reported paths stay intact.
+func appendPath(path Path, seg string) Path {
+	out := make(Path, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, seg)
+}
+
+// lessPath orders paths segment by segment, numerically for indexes.
+func lessPath(a, b Path) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		ai, aErr := strconv.Atoi(a[i])
+		bi, bErr := strconv.Atoi(b[i])
+		if aErr == nil && bErr == nil {
+			return ai < bi
+		}
+		return a[i] < b[i]
+	}
+	return len(a) < len(b)
+}
@@ -0,0 +1,36 @@
+package merge_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestDiff(t *testing.T) {
+	old := M(
+		"db", M("host", "a", "port", 1),
+		"tags", []any{"x", "y"},
+		"gone", true,
+	)
+	new := M(
+		"db", M("host", "a", "port", 2, "user", "u"),
+		"tags", []any{"x"},
+		"ports", map[uint16]any{80: "http"},
+	)
+
+	want := []merge.Change{
+		{Path: merge.Path{"db", "port"}, Kind: merge.Modified, Old: 1, New: 2},
+		{Path: merge.Path{"db", "user"}, Kind: merge.Added, New: "u"},
+		{Path: merge.Path{"gone"}, Kind: merge.Removed, Old: true},
+		{Path: merge.Path{"ports"}, Kind: merge.Added, New: map[uint16]any{80: "http"}},
+		{Path: merge.Path{"tags", "1"}, Kind: merge.Removed, Old: "y"},
+	}
+	if got := merge.Diff(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("Result mismatch:\nGot:      %v\nExpected: %v", got, want)
+	}
+
+	if got := merge.Diff(old, old); len(got) != 0 {
+		t.Errorf("expected no changes, got %v", got)
+	}
+}
@@ -0,0 +1,238 @@
+package merge
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Engine is a registry of merge modes. Engines are independent of each
+// other and safe for concurrent use, registering a mode doesn't race with
+// merges running on the same engine.
+type Engine struct {
+	mu      sync.RWMutex
+	mergers map[Mode]Merger
+	names   map[Mode]string
+	modes   map[string]Mode
+	next    Mode
+	mws     []Middleware
+}
+
+// NewEngine returns an engine with the built-in modes registered.
+func NewEngine() *Engine {
+	e := &Engine{
+		mergers: make(map[Mode]Merger),
+		names:   make(map[Mode]string),
+		modes:   make(map[string]Mode),
+	}
+	e.register("replace", ModeFullReplace, &ReplaceMerger{Mode: ModeFullReplace, Conf: ReplaceMode{Partial: false}})
+	e.register("replace_p", ModePartialReplace, &ReplaceMerger{Mode: ModePartialReplace, Conf: ReplaceMode{Partial: true}})
+	e.register("insert", ModeInsert, &InsertMerger{Mode: ModeInsert})
+	e.register("append", ModeAppend, &InsertMerger{Mode: ModeAppend, Conf: InsertMode{Append: true}})
+	e.register("update", ModeUpdate, &UpdateMerger{Mode: ModeUpdate})
+	e.register("set", ModeSet, &SetMerger{Mode: ModeSet})
+	e.register("prepend", ModePrepend, &InsertMerger{Mode: ModePrepend, Conf: InsertMode{Prepend: true}})
+	e.register("prepend_u", ModePrependUnique, &InsertMerger{Mode: ModePrependUnique, Conf: InsertMode{Prepend: true, Unique: true}})
+	// set algebra on arrays: union adds like insert,
+	// the others only ever remove
+	e.register("union", ModeUnion,
+		Compose(MapInsert, ArrayUnion, SparseAsArray(ArrayUnion), MapInsert, PrimitiveKeep))
+	e.register("intersect", ModeIntersect,
+		Compose(MapUpdate, ArrayIntersect, SparseAsArray(ArrayIntersect), MapUpdate, PrimitiveIgnore))
+	e.register("difference", ModeDifference,
+		Compose(MapUpdate, ArrayDifference, SparseAsArray(ArrayDifference), MapUpdate, PrimitiveIgnore))
+	e.register("symdiff", ModeSymmetricDifference,
+		Compose(MapUpdate, ArraySymmetricDifference, SparseAsArray(ArraySymmetricDifference), MapUpdate, PrimitiveIgnore))
+	e.register("splice", ModeSplice,
+		Compose(MapInsert, ArrayAppend, SparseSplice, MapInsert, PrimitiveKeep))
+	e.register("sum", ModeSum, &NumericMerger{Mode: ModeSum, Combine: PrimitiveSum})
+	e.register("max", ModeMax, &NumericMerger{Mode: ModeMax, Combine: PrimitiveMax})
+	e.register("min", ModeMin, &NumericMerger{Mode: ModeMin, Combine: PrimitiveMin})
+	e.register("avg", ModeAvg, &NumericMerger{Mode: ModeAvg, Combine: PrimitiveAvg})
+	e.register("lww", ModeLWW, &LWWMerger{Mode: ModeLWW})
+	e.next = DefaultMergersCount
+	return e
+}
+
+func (e *Engine) register(name string, mode Mode, m Merger) {
+	e.mergers[mode] = m
+	e.names[mode] = name
+	e.modes[name] = mode
+}
+
+// RegisterMode adds a merger under a new mode and returns that mode.
+// Names must be unique within the engine.
+func (e *Engine) RegisterMode(name string, m Merger) (Mode, error) {
+	if name == "" {
+		return 0, fmt.Errorf("merge mode name must not be empty")
+	}
+	if m == nil {
+		return 0, fmt.Errorf("merge mode %q: merger must not be nil", name)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, exists := e.modes[name]; exists {
+		return 0, fmt.Errorf("merge mode %q already registered", name)
+	}
+	mode := e.next
+	e.next++
+	e.register(name, mode, m)
+	return mode, nil
+}
+
+// Use wraps every mode of the engine, including the ones registered
+// later, with middlewares. See Chain for the order.
+func (e *Engine) Use(mws ...Middleware) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mws = append(e.mws, mws...)
+}
+
+// Merger returns the merger registered for mode wrapped with the
+// engine's middlewares. The strategies set with options such as
+// WithArrayStrategy replace the registered merger inside the middlewares.
+func (e *Engine) Merger(mode Mode) (Merger, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	m, found := e.mergers[mode]
+	if !found {
+		return nil, false
+	}
+	return Chain(strategyMerger{m}, e.mws...), true
+}
+
+// Mode looks up a mode by its name ("replace", "insert", ...).
+func (e *Engine) Mode(name string) (Mode, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	mode, found := e.modes[name]
+	return mode, found
+}
+
+// ModeName returns the name mode was registered with.
+func (e *Engine) ModeName(mode Mode) (string, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	name, found := e.names[mode]
+	return name, found
+}
+
+// Data recursively merges `mergeData` into `orig`.
+// Returns the resulting merged structure or an error on invalid mode or type mismatch.
+func (e *Engine) Data(mode Mode, orig, mergeData any, opts ...Option) (any, error) {
+	ctx := NewContext(e, opts...)
+	if ctx.opts.Copy {
+		orig, mergeData = deepCopy(orig), deepCopy(mergeData)
+	}
+	ctx.passEach = ctx.guarded()
+	res, err := e.data(ctx, mode, orig, mergeData)
+	return ctx.finish(res), err
+}
+
+// Bulk merges every layer into orig in order.
+func (e *Engine) Bulk(orig any, layers ...ModeDataPair) (any, error) {
+	return e.BulkWith(orig, layers)
+}
+
+// BulkWith is Bulk with options, which apply to all layers.
+func (e *Engine) BulkWith(orig any, layers []ModeDataPair, opts ...Option) (any, error) {
+	ctx := NewContext(e, opts...)
+	if ctx.opts.Copy {
+		orig = deepCopy(orig)
+	}
+
+	ctx.passEach = ctx.guarded()
+	for _, layer := range layers {
+		ctx.passEach = ctx.passEach || layer.Policy != nil
+	}
+
+	var err error
+	for i, layer := range layers {
+		ctx.layer, ctx.label, ctx.policy = i, layer.Label, layer.Policy
+		data := layer.Data
+		if ctx.opts.Copy {
+			data = deepCopy(data)
+		}
+		collected := len(ctx.errs)
+		orig, err = e.data(ctx, layer.Mode, orig, data)
+		for j := collected; j < len(ctx.errs); j++ {
+			ctx.errs[j] = ctx.labelError(ctx.errs[j])
+		}
+		if err != nil && !ctx.opts.CollectErrors {
+			return nil, ctx.labelError(err)
+		}
+	}
+	return ctx.finish(orig), ctx.Err()
+}
+
+// labelError prefixes errors of a labeled layer with its label,
+// policy errors carry it already.
+func (c *Context) labelError(err error) error {
+	var perr *PolicyError
+	if c.label == "" || errors.As(err, &perr) {
+		return err
+	}
+	return fmt.Errorf("%s: %w", c.label, err)
+}
+
+func (e *Engine) data(ctx *Context, mode Mode, orig, mergeData any) (any, error) {
+	merger, found := e.Merger(mode)
+	if !found {
+		if _, err := ctx.fail(orig, fmt.Errorf("merger mode %d doesn't exist", mode)); err != nil {
+			return nil, err
+		}
+		return orig, ctx.Err()
+	}
+	// Policies are checked against the layer's input, so it's merged
+	// into a copy and kept intact when the layer is rejected.
+	base := orig
+	if ctx.guarded() {
+		orig = deepCopy(orig)
+	}
+	clear(ctx.avgPending)
+	markers := hasRemoval(mergeData)
+	res, err := UseMerger(ctx, merger, nil, orig, mergeData)
+	if err != nil {
+		return nil, err
+	}
+	if markers {
+		if res, err = ctx.dropRemovals(nil, res); err != nil {
+			return nil, err
+		}
+	}
+	if res == removed {
+		res = nil
+	}
+	if ctx.passEach && len(ctx.opts.ArrayPasses) > 0 {
+		res = ctx.runPasses(nil, res)
+	}
+	if ctx.guarded() {
+		if err := ctx.checkPolicy(base, res); err != nil {
+			clear(ctx.avgPending)
+			if res, err = ctx.fail(base, err); err != nil {
+				return nil, err
+			}
+		}
+	}
+	ctx.commitAvgCounts()
+	return res, ctx.Err()
+}
+
+var defaultEngine = NewEngine()
+
+// DefaultEngine returns the engine used by the package-level functions.
+func DefaultEngine() *Engine {
+	return defaultEngine
+}
+
+// RegisterMode registers a merger on the default engine.
+func RegisterMode(name string, m Merger) (Mode, error) {
+	return defaultEngine.RegisterMode(name, m)
+}
+
+// ParseMode looks up a mode by its name on the default engine.
+func ParseMode(name string) (Mode, bool) {
+	return defaultEngine.Mode(name)
+}
@@ -0,0 +1,83 @@
+package merge_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestEngine_RegisterMode(t *testing.T) {
+	e := merge.NewEngine()
+
+	mode, err := e.RegisterMode("keep", &merge.UpdateMerger{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mode < merge.DefaultMergersCount {
+		t.Errorf("custom mode %d collides with built-in modes", mode)
+	}
+	if got, found := e.Mode("keep"); !found || got != mode {
+		t.Errorf("Mode(keep) = %d, %v; expected %d, true", got, found, mode)
+	}
+	if name, _ := e.ModeName(mode); name != "keep" {
+		t.Errorf("ModeName(%d) = %q, expected keep", mode, name)
+	}
+
+	if _, err := e.RegisterMode("keep", &merge.UpdateMerger{}); err == nil {
+		t.Error("expected duplicate name error")
+	}
+	if _, err := e.RegisterMode("insert", &merge.UpdateMerger{}); err == nil {
+		t.Error("expected error when shadowing a built-in mode")
+	}
+	if _, err := e.RegisterMode("nil", nil); err == nil {
+		t.Error("expected error for nil merger")
+	}
+
+	res, err := e.Data(mode, M("a", 1), M("a", 2, "b", 3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(toJSON(res), `"a": 2`) || strings.Contains(toJSON(res), `"b"`) {
+		t.Errorf("custom mode not applied: %s", toJSON(res))
+	}
+}
+
+func TestEngine_Isolation(t *testing.T) {
+	e := merge.NewEngine()
+	mode, err := e.RegisterMode("isolated", &merge.UpdateMerger{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, found := merge.ParseMode("isolated"); found {
+		t.Error("mode registered on an engine leaked into the default engine")
+	}
+	if _, err := merge.Data(mode, M(), M()); err == nil {
+		t.Error("expected unknown mode error from the default engine")
+	}
+}
+
+func TestEngine_ConcurrentRegister(t *testing.T) {
+	e := merge.NewEngine()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := e.RegisterMode(fmt.Sprintf("mode%d", i), &merge.InsertMerger{}); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := e.Bulk(M("a", 1), merge.ModeDataPair{Mode: merge.ModeInsert, Data: M("b", 2)}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
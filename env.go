@@ -0,0 +1,52 @@
+package merge
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// FromEnv turns environment variables starting with prefix into merge data.
+// The rest of the variable name is split by sep into path segments:
+//
+//	APP_DB__HOST=x          -> {"db": {"host": "x"}}
+//	APP_SERVERS__0__PORT=80 -> {"servers": map[int]any{0: {"port": 80}}}
+//
+// Segments are matched against the keys of shape (the base config), exactly
+// first and then case-insensitively, and lowercased when shape has no such
+// key. A segment matching several keys only by case is an error. Segments
+// addressing a []any in shape become map[int]any sparse arrays. Values are
+// coerced to the type found at the same path in shape; containers are
+// decoded from JSON, anything unknown stays a string.
+func FromEnv(prefix, sep string, shape any) (map[string]any, error) {
+	return fromEnviron(os.Environ(), prefix, sep, shape)
+}
+
+func fromEnviron(environ []string, prefix, sep string, shape any) (map[string]any, error) {
+	if sep == "" {
+		return nil, fmt.Errorf("env separator must not be empty")
+	}
+	sort.Strings(environ)
+
+	out := make(map[string]any)
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+
+		segs, leaf, err := resolvePath(shape, strings.Split(name[len(prefix):], sep))
+		if err != nil {
+			return nil, fmt.Errorf("env %s: %w", name, err)
+		}
+		v, err := coerceString(leaf, value)
+		if err != nil {
+			return nil, fmt.Errorf("env %s: %w", name, err)
+		}
+		if err := setOverlay(out, segs, v); err != nil {
+			return nil, fmt.Errorf("env %s: %w", name, err)
+		}
+	}
+	return out, nil
+}
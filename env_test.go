@@ -0,0 +1,97 @@
+package merge_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestFromEnv(t *testing.T) {
+	shape := M(
+		"db", M("host", "localhost", "port", 5432, "ssl", false),
+		"servers", []any{M("port", uint16(80)), M("port", uint16(81))},
+		"ratio", 0.5,
+		"tags", []any{"a"},
+		"camelCase", "x",
+	)
+
+	t.Setenv("APP_DB__HOST", "db.internal")
+	t.Setenv("APP_DB__PORT", "6432")
+	t.Setenv("APP_DB__SSL", "true")
+	t.Setenv("APP_SERVERS__1__PORT", "8080")
+	t.Setenv("APP_RATIO", "0.75")
+	t.Setenv("APP_TAGS", `["b", "c"]`)
+	t.Setenv("APP_CAMELCASE", "y")
+	t.Setenv("APP_NEW__KEY", "v")
+	t.Setenv("OTHER_DB__HOST", "ignored")
+
+	got, err := merge.FromEnv("APP_", "__", shape)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := M(
+		"db", M("host", "db.internal", "port", 6432, "ssl", true),
+		"servers", map[int]any{1: M("port", uint16(8080))},
+		"ratio", 0.75,
+		"tags", []any{"b", "c"},
+		"camelCase", "y",
+		"new", M("key", "v"),
+	)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Result mismatch:\nGot:      %s\nExpected: %s", toJSON(got), toJSON(want))
+	}
+
+	merged, err := merge.Data(merge.ModePartialReplace, shape, got)
+	if err != nil {
+		t.Fatalf("unexpected merge error: %v", err)
+	}
+	servers := merged.(map[string]any)["servers"].([]any)
+	if p := servers[1].(map[string]any)["port"]; p != uint16(8080) {
+		t.Errorf("expected servers.1.port to be overridden, got %v", p)
+	}
+}
+
+func TestFromEnv_Errors(t *testing.T) {
+	shape := M("db", M("port", 5432), "servers", []any{M("port", 80)})
+
+	cases := []struct {
+		name, key, value, errMsg string
+	}{
+		{"bad number", "APPX_DB__PORT", "abc", "APPX_DB__PORT"},
+		{"bad index", "APPX_SERVERS__X__PORT", "1", "expected array index"},
+		{"map from bad JSON", "APPX_DB", "x", "expected JSON"},
+		{"conflict", "APPX_DB__HOST__NAME", "x", "conflicting value"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("APPX_DB__HOST", "h")
+			t.Setenv(tc.key, tc.value)
+			_, err := merge.FromEnv("APPX_", "__", shape)
+			if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Errorf("expected error containing %q, got %v", tc.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestFromEnv_KeysDifferingByCase(t *testing.T) {
+	shape := M("Host", "a", "host", "b", "Port", 1)
+
+	t.Setenv("APPC_host", "x")
+	t.Setenv("APPC_PORT", "2")
+	got, err := merge.FromEnv("APPC_", "__", shape)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := M("host", "x", "Port", 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %s, expected %s", toJSON(got), toJSON(want))
+	}
+
+	t.Setenv("APPC_HOST", "y")
+	if _, err := merge.FromEnv("APPC_", "__", shape); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected ambiguous key error, got %v", err)
+	}
+}
@@ -0,0 +1,331 @@
+package merge
+
+import (
+	"errors"
+	"fmt"
+	pathpkg "path"
+	"strconv"
+	"strings"
+)
+
+// ErrFiltered is returned with WithFilterErrors
+// when merge data touches a path that's filtered out.
+var ErrFiltered = errors.New("path is filtered out")
+
+// WithInclude restricts the merge to paths matching any of patterns and
+// everything below them. Patterns are dot separated segments, each one a
+// path.Match glob ("*" matches a single key or index), "**" matches any
+// number of segments: "features.*", "servers.*.port", "**.enabled".
+func WithInclude(patterns ...string) Option {
+	return func(o *Options) { o.Include = append(o.Include, patterns...) }
+}
+
+// WithExclude leaves paths matching any of patterns, and everything below
+// them, untouched. See WithInclude for the pattern syntax.
+func WithExclude(patterns ...string) Option {
+	return func(o *Options) { o.Exclude = append(o.Exclude, patterns...) }
+}
+
+// WithOnSkip calls fn for every path skipped by include/exclude filters.
+func WithOnSkip(fn func(path Path)) Option {
+	return func(o *Options) { o.OnSkip = fn }
+}
+
+// WithFilterErrors fails the merge with ErrFiltered instead of
+// skipping filtered out paths.
+func WithFilterErrors() Option {
+	return func(o *Options) { o.FilterErrors = true }
+}
+
+func parsePatterns(patterns []string) [][]string {
+	out := make([][]string, len(patterns))
+	for i, p := range patterns {
+		out[i] = strings.Split(p, ".")
+	}
+	return out
+}
+
+// matchPattern reports whether pat matches the whole path.
+func matchPattern(pat, path []string) bool {
+	if len(pat) == 0 {
+		return len(path) == 0
+	}
+	if pat[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchPattern(pat[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, _ := pathpkg.Match(pat[0], path[0]); !ok {
+		return false
+	}
+	return matchPattern(pat[1:], path[1:])
+}
+
+// coversPath reports whether pat matches path or any of its ancestors.
+func coversPath(pat, path []string) bool {
+	for i := 0; i <= len(path); i++ {
+		if matchPattern(pat, path[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesBelow reports whether pat may match paths below path.
+func matchesBelow(pat, path []string) bool {
+	if len(path) == 0 {
+		return len(pat) > 0
+	}
+	if len(pat) == 0 {
+		return false
+	}
+	if pat[0] == "**" {
+		return true
+	}
+	if ok, _ := pathpkg.Match(pat[0], path[0]); !ok {
+		return false
+	}
+	return matchesBelow(pat[1:], path[1:])
+}
+
+type filterState int
+
+const (
+	filterAllow filterState = iota
+	filterSkip
+	// filterAncestor is above included paths, only parts of it may change.
+	filterAncestor
+	// filterGuarded has excluded paths below it.
+	filterGuarded
+)
+
+func (c *Context) filter(path []string) filterState {
+	if len(c.include) == 0 && len(c.exclude) == 0 {
+		return filterAllow
+	}
+	for _, pat := range c.exclude {
+		if coversPath(pat, path) {
+			return filterSkip
+		}
+	}
+
+	if len(c.include) > 0 {
+		covered, above := false, false
+		for _, pat := range c.include {
+			covered = covered || coversPath(pat, path)
+			above = above || matchesBelow(pat, path)
+		}
+		switch {
+		case !covered && above:
+			return filterAncestor
+		case !covered:
+			return filterSkip
+		}
+	}
+
+	for _, pat := range c.exclude {
+		if matchesBelow(pat, path) {
+			return filterGuarded
+		}
+	}
+	return filterAllow
+}
+
+// skip reports a filtered out path and keeps orig.
+func (c *Context) skip(path []string, orig any) (any, error) {
+	if c.opts.FilterErrors {
+		return c.fail(orig, fmt.Errorf("merge error at %v: %w", formatPath(path), ErrFiltered))
+	}
+	if c.opts.OnSkip != nil {
+		c.opts.OnSkip(append(Path(nil), path...))
+	}
+	return orig, nil
+}
+
+// applyFilter merges a node whose subtree is only partly allowed:
+// containers are merged as usual (children check filters on their own)
+// with additions pruned, everything else is pruned as a whole.
+func (c *Context) applyFilter(state filterState, path []string, orig, mergeData any, merge func() (any, error)) (any, error) {
+	if state == filterSkip {
+		return c.skip(path, orig)
+	}
+
+	if mergeData == nil && isContainer(orig) {
+		return c.skip(path, orig)
+	}
+
+	delete(c.ownPruned, pathKey(path))
+	res, err := merge()
+	if err != nil {
+		return nil, err
+	}
+
+	if isContainer(orig) && isContainer(mergeData) {
+		if o, ok := orig.([]any); ok && !c.takePrunedOwn(path) {
+			return c.pruneAppended(path, len(o), res)
+		}
+		return res, nil
+	}
+	if state == filterAncestor && !isContainer(mergeData) {
+		return c.skip(path, orig)
+	}
+
+	pruned, keep, err := c.prune(path, res)
+	if err != nil || !keep {
+		return orig, err
+	}
+	return pruned, nil
+}
+
+// pruneAppended prunes elements added to an array after origLen.
+// Strategies placing merge data anywhere else prune their additions
+// on their own and report it with prunedOwn.
+func (c *Context) pruneAppended(path []string, origLen int, res any) (any, error) {
+	arr, ok := res.([]any)
+	if !ok || len(arr) <= origLen {
+		return res, nil
+	}
+	out := arr[:origLen]
+	for i, v := range arr[origLen:] {
+		pruned, keep, err := c.prune(append(path, strconv.Itoa(origLen+i)), v)
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			out = append(out, pruned)
+		}
+	}
+	return out, nil
+}
+
+// prunedOwn records that the array at path was merged by a strategy
+// adding values through ctx.addition, pruning them at their final index.
+// Such strategies don't just append, so pruneAppended would prune the
+// wrong elements.
+func (c *Context) prunedOwn(path []string) {
+	if c.ownPruned == nil {
+		c.ownPruned = make(map[string]bool)
+	}
+	c.ownPruned[pathKey(path)] = true
+}
+
+// takePrunedOwn reports and forgets whether prunedOwn was called for path.
+func (c *Context) takePrunedOwn(path []string) bool {
+	key := pathKey(path)
+	own := c.ownPruned[key]
+	delete(c.ownPruned, key)
+	return own
+}
+
+// truncate cuts arr to n elements. Elements filters keep from being
+// removed survive, and so does everything before them to keep indexes.
+func (c *Context) truncate(path []string, arr []any, n int) ([]any, error) {
+	for i := len(arr) - 1; i >= n; i-- {
+		p := append(path, strconv.Itoa(i))
+		if !c.removable(p, arr[i]) {
+			_, err := c.skip(p, nil)
+			return arr[:i+1], err
+		}
+	}
+	return arr[:min(n, len(arr))], nil
+}
+
+// removable reports whether filters allow removing v at path
+// along with everything below it. Leaves only guarded by patterns
+// that could match below them have nothing excluded.
+func (c *Context) removable(path []string, v any) bool {
+	switch c.filter(path) {
+	case filterAllow:
+		return true
+	case filterSkip:
+		return false
+	}
+	return everyLeaf(path, v, func(p []string) bool {
+		state := c.filter(p)
+		return state == filterAllow || state == filterGuarded
+	})
+}
+
+// everyLeaf reports whether pred holds for every leaf below path in v,
+// or for path itself when v is a leaf. A nil v has no leaves.
+func everyLeaf(path Path, v any, pred func(path []string) bool) bool {
+	if v == nil {
+		return true
+	}
+	if !isBranch(v) {
+		return pred(path)
+	}
+	ok := true
+	walk(path, v, func(p Path, v any) {
+		ok = ok && (isBranch(v) || pred(p))
+	})
+	return ok
+}
+
+// prune returns v without its filtered out parts, keep is false
+// when v is filtered out as a whole.
+func (c *Context) prune(path []string, v any) (any, bool, error) {
+	state := c.filter(path)
+	switch state {
+	case filterAllow:
+		return v, true, nil
+	case filterSkip:
+		_, err := c.skip(path, nil)
+		return nil, false, err
+	}
+
+	switch t := v.(type) {
+	case map[string]any:
+		out, err := pruneMap(c, path, t)
+		return out, err == nil, err
+	case map[int]any:
+		out, err := pruneMap(c, path, t)
+		return out, err == nil, err
+	case []any:
+		out := make([]any, 0, len(t))
+		for i, elem := range t {
+			pruned, keep, err := c.prune(append(path, strconv.Itoa(i)), elem)
+			if err != nil {
+				return nil, false, err
+			}
+			if keep {
+				out = append(out, pruned)
+			}
+		}
+		return out, true, nil
+	}
+
+	if state == filterAncestor {
+		_, err := c.skip(path, nil)
+		return nil, false, err
+	}
+	return v, true, nil
+}
+
+func pruneMap[K comparable](c *Context, path []string, m map[K]any) (map[K]any, error) {
+	out := make(map[K]any, len(m))
+	for k, v := range m {
+		pruned, keep, err := c.prune(append(path, pathSegment(k)), v)
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			out[k] = pruned
+		}
+	}
+	return out, nil
+}
+
+func isContainer(v any) bool {
+	switch v.(type) {
+	case map[string]any, map[int]any, []any:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package merge_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestFilters(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "exclude subtree",
+			Mode:     merge.ModeFullReplace,
+			Original: M("security", M("tls", true), "db", M("host", "a")),
+			Merge:    M("security", M("tls", false, "ciphers", "weak"), "db", M("host", "b")),
+			Options:  []merge.Option{merge.WithExclude("security")},
+			Expected: M("security", M("tls", true), "db", M("host", "b")),
+		},
+		{
+			Name:     "exclude subtree missing in orig",
+			Mode:     merge.ModeInsert,
+			Original: M("db", M("host", "a")),
+			Merge:    M("security", M("tls", false)),
+			Options:  []merge.Option{merge.WithExclude("security")},
+			Expected: M("db", M("host", "a")),
+		},
+		{
+			Name:     "exclude glob at any depth",
+			Mode:     merge.ModeFullReplace,
+			Original: M("db", M("user", "a", "password", "secret")),
+			Merge:    M("db", M("user", "b", "password", "hacked"), "cache", M("password", "x", "ttl", 5)),
+			Options:  []merge.Option{merge.WithExclude("**.password")},
+			Expected: M("db", M("user", "b", "password", "secret"), "cache", M("ttl", 5)),
+		},
+		{
+			Name:     "include subtree",
+			Mode:     merge.ModeFullReplace,
+			Original: M("features", M("a", false), "db", M("host", "a")),
+			Merge:    M("features", M("a", true, "b", true), "db", M("host", "b"), "new", 1),
+			Options:  []merge.Option{merge.WithInclude("features.*")},
+			Expected: M("features", M("a", true, "b", true), "db", M("host", "a")),
+		},
+		{
+			Name:     "include leaves of array elements",
+			Mode:     merge.ModeFullReplace,
+			Original: M("servers", []any{M("host", "a", "port", 1)}),
+			Merge:    M("servers", []any{M("host", "b", "port", 2), M("host", "c", "port", 3)}),
+			Options:  []merge.Option{merge.WithInclude("servers.*.port")},
+			Expected: M("servers", []any{M("host", "a", "port", 2), M("port", 3)}),
+		},
+		{
+			Name:     "include does not replace ancestors wholesale",
+			Mode:     merge.ModeFullReplace,
+			Original: M("features", M("a", true)),
+			Merge:    M("features", nil),
+			Options:  []merge.Option{merge.WithInclude("features.a"), merge.WithNullPolicy(merge.NullSet)},
+			Expected: M("features", M("a", true)),
+		},
+		{
+			Name:      "filter errors",
+			Mode:      merge.ModeFullReplace,
+			Original:  M("security", M("tls", true)),
+			Merge:     M("security", M("tls", false)),
+			Options:   []merge.Option{merge.WithExclude("security.tls"), merge.WithFilterErrors()},
+			ShouldErr: true,
+			ErrMsg:    "path is filtered out",
+		},
+	}
+
+	TableTest(t, cases)
+}
+
+func TestFilters_OnSkip(t *testing.T) {
+	var skipped []string
+	_, err := merge.Data(merge.ModeFullReplace,
+		M("security", M("tls", true), "db", M("password", "a")),
+		M("security", M("tls", false), "db", M("password", "b"), "new", M("password", "c")),
+		merge.WithExclude("security", "**.password"),
+		merge.WithOnSkip(func(p merge.Path) { skipped = append(skipped, p.String()) }),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sort.Strings(skipped)
+	want := []string{"db.password", "new.password", "security"}
+	if !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped %v, expected %v", skipped, want)
+	}
+
+	_, err = merge.Data(merge.ModeFullReplace, M("a", 1), M("a", 2),
+		merge.WithInclude("b"), merge.WithFilterErrors())
+	if !errors.Is(err, merge.ErrFiltered) {
+		t.Errorf("expected ErrFiltered, got %v", err)
+	}
+}
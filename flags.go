@@ -0,0 +1,149 @@
+package merge
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FlagOverlay collects the flags registered by BindFlags.
+type FlagOverlay struct {
+	flags []*overlayFlag
+}
+
+// BindFlags registers a flag on fs for every leaf of base:
+//
+//	{"db": {"host": "x"}}            -> --db.host
+//	{"servers": [{"port": 80}]}      -> --servers[0].port
+//
+// Flag values are parsed into the type of the base value, containers
+// (empty maps and arrays) are set as JSON. Boolean flags can be set
+// without a value. Only map[string]any is walked, typed maps
+// (map[string]string, map[int]any, ...) are skipped. Leaves whose flag
+// names collide, e.g. the keys "a.b" and "a" -> "b", are already defined
+// on fs, or can't be flag names (empty, starting with "-" or containing
+// "="), are an error.
+func BindFlags(fs *flag.FlagSet, base any) (*FlagOverlay, error) {
+	o := &FlagOverlay{}
+	if err := o.bind(fs, nil, base); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *FlagOverlay) bind(fs *flag.FlagSet, segs []overlaySeg, v any) error {
+	switch c := v.(type) {
+	case map[string]any:
+		if len(c) > 0 {
+			keys := make([]string, 0, len(c))
+			for k := range c {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if err := o.bind(fs, append(segs[:len(segs):len(segs)], overlaySeg{Key: k}), c[k]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case []any:
+		if len(c) > 0 {
+			for i, elem := range c {
+				if err := o.bind(fs, append(segs[:len(segs):len(segs)], overlaySeg{Index: i, IsIndex: true}), elem); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	default:
+		if v == nil || reflect.TypeOf(v).Kind() == reflect.Map {
+			return nil
+		}
+	}
+	if len(segs) == 0 {
+		return nil
+	}
+
+	name := flagName(segs)
+	if name == "" || strings.HasPrefix(name, "-") || strings.Contains(name, "=") {
+		return fmt.Errorf("flag name %q for %q is invalid", name, pathOf(segs))
+	}
+	if fs.Lookup(name) != nil {
+		return fmt.Errorf("flag --%s for %q is already defined", name, pathOf(segs))
+	}
+	f := &overlayFlag{segs: segs, shape: v}
+	o.flags = append(o.flags, f)
+	fs.Var(f, name, fmt.Sprintf("override %v", pathOf(segs)))
+	return nil
+}
+
+// Data returns merge data holding only the flags that were set, meant to
+// be applied with ModeFullReplace: leaves replace the base values, arrays
+// set as JSON replace the base arrays as a whole.
+func (o *FlagOverlay) Data() (map[string]any, error) {
+	out := make(map[string]any)
+	for _, f := range o.flags {
+		if !f.set {
+			continue
+		}
+		if err := setOverlay(out, f.segs, f.value); err != nil {
+			return nil, fmt.Errorf("flag %s: %w", flagName(f.segs), err)
+		}
+	}
+	return out, nil
+}
+
+func flagName(segs []overlaySeg) string {
+	var b strings.Builder
+	for i, seg := range segs {
+		if seg.IsIndex {
+			b.WriteString("[" + strconv.Itoa(seg.Index) + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(seg.Key)
+	}
+	return b.String()
+}
+
+type overlayFlag struct {
+	segs  []overlaySeg
+	shape any
+	value any
+	set   bool
+}
+
+func (f *overlayFlag) String() string {
+	if f == nil || f.shape == nil {
+		return ""
+	}
+	v := f.shape
+	if f.set {
+		v = f.value
+	}
+	switch v.(type) {
+	case map[string]any, []any:
+		return compactJSON(v)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func (f *overlayFlag) Set(s string) error {
+	v, err := coerceString(f.shape, s)
+	if err != nil {
+		return err
+	}
+	f.value, f.set = v, true
+	return nil
+}
+
+func (f *overlayFlag) IsBoolFlag() bool {
+	_, ok := f.shape.(bool)
+	return ok
+}
@@ -0,0 +1,100 @@
+package merge_test
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestBindFlags(t *testing.T) {
+	base := M(
+		"db", M("host", "localhost", "port", 5432, "ssl", false),
+		"servers", []any{M("port", 80), M("port", 81)},
+		"tags", []any{},
+	)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	overlay, err := merge.BindFlags(fs, base)
+	if err != nil {
+		t.Fatalf("unexpected bind error: %v", err)
+	}
+
+	for _, name := range []string{"db.host", "db.port", "db.ssl", "servers[0].port", "servers[1].port", "tags"} {
+		if fs.Lookup(name) == nil {
+			t.Errorf("flag --%s not registered", name)
+		}
+	}
+	if def := fs.Lookup("db.port").DefValue; def != "5432" {
+		t.Errorf("expected default 5432, got %q", def)
+	}
+
+	err = fs.Parse([]string{"--db.port=6432", "--db.ssl", "--servers[1].port", "8081", "--tags", `["a"]`})
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	got, err := overlay.Data()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := M(
+		"db", M("port", 6432, "ssl", true),
+		"servers", map[int]any{1: M("port", 8081)},
+		"tags", []any{"a"},
+	)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Result mismatch:\nGot:      %s\nExpected: %s", toJSON(got), toJSON(want))
+	}
+
+	merged, err := merge.Data(merge.ModeFullReplace, base, got)
+	if err != nil {
+		t.Fatalf("unexpected merge error: %v", err)
+	}
+	wantMerged := M(
+		"db", M("host", "localhost", "port", 6432, "ssl", true),
+		"servers", []any{M("port", 80), M("port", 8081)},
+		"tags", []any{"a"},
+	)
+	if !reflect.DeepEqual(merged, wantMerged) {
+		t.Errorf("Result mismatch:\nGot:      %s\nExpected: %s", toJSON(merged), toJSON(wantMerged))
+	}
+}
+
+func TestBindFlags_InvalidValue(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if _, err := merge.BindFlags(fs, M("port", 80)); err != nil {
+		t.Fatalf("unexpected bind error: %v", err)
+	}
+
+	if err := fs.Parse([]string{"--port=http"}); err == nil {
+		t.Error("expected parse error for non-numeric port")
+	}
+}
+
+func TestBindFlags_Collision(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	_, err := merge.BindFlags(fs, M("a.b", 1, "a", M("b", 2)))
+	if err == nil || !strings.Contains(err.Error(), "--a.b") {
+		t.Errorf("expected collision error naming --a.b, got %v", err)
+	}
+}
+
+func TestBindFlags_InvalidName(t *testing.T) {
+	for _, base := range []map[string]any{M("a=b", 1), M("-v", true), M("", "x")} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+
+		_, err := merge.BindFlags(fs, base)
+		if err == nil || !strings.Contains(err.Error(), "is invalid") {
+			t.Errorf("%v: expected invalid name error, got %v", base, err)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package merge
 
 import (
+	"fmt"
 	"math"
 	"reflect"
+	"strings"
 )
 
 func isZeroValue(x any) bool {
@@ -25,13 +27,19 @@ func isZeroValue(x any) bool {
 	return false
 }
 
-func nonZero(a, b any) any {
-	if isZeroValue(a) {
-		return b
+// pathKey joins path with dots like Path.String, but escapes dots and
+// backslashes in segments, so ["a.b"] and ["a", "b"] don't collide.
+// The root is "".
+func pathKey(path []string) string {
+	segs := make([]string, len(path))
+	for i, seg := range path {
+		segs[i] = keyEscaper.Replace(seg)
 	}
-	return a
+	return strings.Join(segs, ".")
 }
 
+var keyEscaper = strings.NewReplacer(`\`, `\\`, ".", `\.`)
+
 func sparseArrayToArray[T any](sparceArr map[int]T) []T {
 	var (
 		minK int = math.MaxInt32
@@ -58,3 +66,65 @@ func contains(arr []any, val any) bool {
 	}
 	return false
 }
+
+// toFloat returns the value of any Go number as float64,
+// ok is false for everything else.
+func toFloat(v any) (f float64, ok bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// compareValues orders numbers numerically, strings and bools naturally
+// (false first) and anything else, or values of different kinds, by their
+// type and then their formatted value. nil comes first.
+func compareValues(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			switch {
+			case fa < fb:
+				return -1
+			case fa > fb:
+				return 1
+			}
+			return 0
+		}
+	}
+	if sa, ok := a.(string); ok {
+		if sb, ok := b.(string); ok {
+			return strings.Compare(sa, sb)
+		}
+	}
+	if ba, ok := a.(bool); ok {
+		if bb, ok := b.(bool); ok {
+			switch {
+			case ba == bb:
+				return 0
+			case !ba:
+				return -1
+			}
+			return 1
+		}
+	}
+
+	if ta, tb := fmt.Sprintf("%T", a), fmt.Sprintf("%T", b); ta != tb {
+		return strings.Compare(ta, tb)
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
@@ -0,0 +1,116 @@
+package merge
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// pathSegment renders a map key or index as a single path segment.
+// Strings (including named string types) are used as is, fmt.Stringer
+// implementations through String, structs with their field names.
+func pathSegment(k any) string {
+	switch key := k.(type) {
+	case string:
+		return key
+	case int:
+		return strconv.Itoa(key)
+	case fmt.Stringer:
+		return key.String()
+	}
+	v := reflect.ValueOf(k)
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Struct:
+		return fmt.Sprintf("%+v", k)
+	}
+	return fmt.Sprintf("%v", k)
+}
+
+// toAnyMap copies a map of any key and value type into a map[any]any.
+func toAnyMap(v reflect.Value) map[any]any {
+	if v.IsNil() {
+		return nil
+	}
+	out := make(map[any]any, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		out[iter.Key().Interface()] = iter.Value().Interface()
+	}
+	return out
+}
+
+// fromAnyMap writes merged back into orig, which keeps the in-place
+// semantics of the other map kinds. A nil orig is replaced by a new map
+// of the same type.
+func fromAnyMap(path []string, orig reflect.Value, merged map[any]any) (any, error) {
+	t := orig.Type()
+	out := orig
+	if out.IsNil() {
+		if merged == nil {
+			return orig.Interface(), nil
+		}
+		out = reflect.MakeMapWithSize(t, len(merged))
+	}
+
+	for _, k := range out.MapKeys() {
+		if _, exists := merged[k.Interface()]; !exists {
+			out.SetMapIndex(k, reflect.Value{})
+		}
+	}
+
+	elemType := t.Elem()
+	for k, v := range merged {
+		key := reflect.ValueOf(k)
+		if !key.Type().AssignableTo(t.Key()) {
+			return nil, typeMismatch(path, t.Key().String(), k)
+		}
+
+		val := reflect.Zero(elemType)
+		if v != nil {
+			val = reflect.ValueOf(v)
+			if !val.Type().AssignableTo(elemType) {
+				return nil, typeMismatch(append(path, pathSegment(k)), elemType.String(), v)
+			}
+		}
+		out.SetMapIndex(key, val)
+	}
+	return out.Interface(), nil
+}
+
+func useAnyMap(ctx *Context, m Merger, path []string, o reflect.Value, mergeData any) (any, error) {
+	if mergeData == nil {
+		return o.Interface(), nil
+	}
+	md := reflect.ValueOf(mergeData)
+	if md.Type() != o.Type() {
+		return ctx.fail(o.Interface(), typeMismatch(path, o.Type().String(), mergeData))
+	}
+
+	res, err := MergeAnyMap(ctx, m, m, path, toAnyMap(o), toAnyMap(md))
+	if err != nil {
+		return ctx.fail(o.Interface(), fmt.Errorf("keyed map merge failed at %v: %w", formatPath(path), err))
+	}
+	out, err := fromAnyMap(path, o, res)
+	if err != nil {
+		return ctx.fail(o.Interface(), err)
+	}
+	return out, nil
+}
+
+// MergeAnyMap merges keyed maps with m. Middlewares embedding the Merger
+// they wrap implement AnyMapMerger by forwarding to it:
+//
+//	func (l logMerger) MergeAnyMap(ctx *merge.Context, next merge.Merger, path []string, orig, data map[any]any) (map[any]any, error) {
+//		return merge.MergeAnyMap(ctx, l.Merger, next, path, orig, data)
+//	}
+//
+// It fails when m doesn't implement AnyMapMerger, rather than merging the
+// maps in a way the mode may not.
+func MergeAnyMap(ctx *Context, m, next Merger, path []string, orig, mergeData map[any]any) (map[any]any, error) {
+	if am, ok := m.(AnyMapMerger); ok {
+		return am.MergeAnyMap(ctx, next, path, orig, mergeData)
+	}
+	return nil, fmt.Errorf("%T doesn't implement AnyMapMerger", m)
+}
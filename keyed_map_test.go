@@ -0,0 +1,122 @@
+package merge_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+type envName string
+
+type hostPort struct {
+	Host string
+	Port int
+}
+
+func TestKeyedMaps(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "int64 keys insert",
+			Mode:     merge.ModeInsert,
+			Original: map[int64]any{1: "a", 2: "b"},
+			Merge:    map[int64]any{2: "B", 3: "c"},
+			Expected: map[int64]any{1: "a", 2: "b", 3: "c"},
+		},
+		{
+			Name:     "uint keys update",
+			Mode:     merge.ModeUpdate,
+			Original: map[uint]any{1: "a", 2: "b"},
+			Merge:    map[uint]any{2: "B", 3: "c"},
+			Expected: map[uint]any{1: "a", 2: "B"},
+		},
+		{
+			Name:     "named string keys merge recursively",
+			Mode:     merge.ModeFullReplace,
+			Original: map[envName]any{"prod": M("replicas", 3, "region", "eu")},
+			Merge:    map[envName]any{"prod": M("replicas", 5), "dev": M("replicas", 1)},
+			Expected: map[envName]any{"prod": M("replicas", 5, "region", "eu"), "dev": M("replicas", 1)},
+		},
+		{
+			Name:     "struct keys",
+			Mode:     merge.ModePartialReplace,
+			Original: map[hostPort]any{{"a", 1}: "up"},
+			Merge:    map[hostPort]any{{"a", 1}: "down", {"b", 2}: "up"},
+			Expected: map[hostPort]any{{"a", 1}: "down"},
+		},
+		{
+			Name:     "typed values",
+			Mode:     merge.ModeFullReplace,
+			Original: map[int64]string{1: "a"},
+			Merge:    map[int64]string{1: "A", 2: "b"},
+			Expected: map[int64]string{1: "A", 2: "b"},
+		},
+		{
+			Name:     "insert into nil map",
+			Mode:     merge.ModeInsert,
+			Original: map[int64]any(nil),
+			Merge:    map[int64]any{1: "a"},
+			Expected: map[int64]any{1: "a"},
+		},
+		{
+			Name:     "nested keyed map",
+			Mode:     merge.ModeInsert,
+			Original: M("ports", map[uint16]any{80: M("proto", "http")}),
+			Merge:    M("ports", map[uint16]any{80: M("tls", false), 443: M("proto", "https")}),
+			Expected: M("ports", map[uint16]any{80: M("proto", "http", "tls", false), 443: M("proto", "https")}),
+		},
+		{
+			Name:      "key type mismatch",
+			Mode:      merge.ModeInsert,
+			Original:  map[int64]any{1: "a"},
+			Merge:     map[int32]any{1: "a"},
+			ShouldErr: true,
+			ErrMsg:    "expected map[int64]interface {}",
+		},
+		{
+			Name:      "error path contains struct key",
+			Mode:      merge.ModeInsert,
+			Original:  map[hostPort]any{{"a", 1}: M("x", 1)},
+			Merge:     map[hostPort]any{{"a", 1}: []any{1}},
+			ShouldErr: true,
+			ErrMsg:    "{Host:a Port:1}",
+		},
+	}
+
+	TableTest(t, cases)
+}
+
+// basicMerger implements only the Merger methods of the mode it wraps.
+type basicMerger struct {
+	merge.Merger
+}
+
+// forwardingMerger is a basicMerger forwarding keyed maps too.
+type forwardingMerger struct {
+	merge.Merger
+}
+
+func (f forwardingMerger) MergeAnyMap(ctx *merge.Context, next merge.Merger, path []string, orig, data map[any]any) (map[any]any, error) {
+	return merge.MergeAnyMap(ctx, f.Merger, next, path, orig, data)
+}
+
+func TestKeyedMaps_WithoutAnyMapMerger(t *testing.T) {
+	e := merge.NewEngine()
+	e.Use(func(m merge.Merger) merge.Merger { return basicMerger{m} })
+
+	_, err := e.Data(merge.ModeUpdate, map[int64]any{1: "a"}, map[int64]any{1: "A", 2: "b"})
+	if err == nil || !strings.Contains(err.Error(), "doesn't implement AnyMapMerger") {
+		t.Errorf("expected an AnyMapMerger error, got %v", err)
+	}
+
+	e = merge.NewEngine()
+	e.Use(func(m merge.Merger) merge.Merger { return forwardingMerger{m} })
+	res, err := e.Data(merge.ModeUpdate, map[int64]any{1: "a"}, map[int64]any{1: "A", 2: "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[int64]any{1: "A"}; !reflect.DeepEqual(res, want) {
+		t.Errorf("got %v, expected %v", res, want)
+	}
+}
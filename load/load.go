@@ -0,0 +1,202 @@
+// Package load reads an ordered list of configuration files and folds them
+// into a single tree with the merge package.
+//
+// Every file is a layer with its own merge mode. Modes are given either
+// with a "path:mode" suffix or with a sidecar file next to the layer
+// ("config.json.mode" containing e.g. "update"). Layers without a mode use
+// merge.DefaultMergeMode.
+//
+// JSON and the YAML subset configuration files use are decoded out of the
+// box; other formats (TOML, ...) or a full YAML implementation are plugged
+// in with RegisterDecoder so this package stays dependency free.
+package load
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+// SidecarExt is appended to a layer path to find its sidecar mode file.
+const SidecarExt = ".mode"
+
+// Decoder turns raw file contents into a merge tree
+// (map[string]any, []any and primitives).
+type Decoder func(data []byte) (any, error)
+
+// Layer is a single file applied on top of the previous ones.
+type Layer struct {
+	Path string
+	Mode merge.Mode
+	// Format overrides the decoder picked from the file extension.
+	// Required when Path is "-" (stdin) or has no extension.
+	Format string
+}
+
+type Loader struct {
+	decoders map[string]Decoder
+	// Engine resolves mode names and merges layers,
+	// merge.DefaultEngine() when nil.
+	Engine *merge.Engine
+	// Options are passed to every merge.
+	Options []merge.Option
+	// Stdin is read for layers with the "-" path.
+	Stdin io.Reader
+}
+
+// New returns a Loader with the JSON and YAML decoders registered.
+func New() *Loader {
+	l := &Loader{
+		decoders: make(map[string]Decoder),
+		Stdin:    os.Stdin,
+	}
+	l.RegisterDecoder("json", DecodeJSON)
+	l.RegisterDecoder("yaml", DecodeYAML)
+	l.RegisterDecoder("yml", DecodeYAML)
+	return l
+}
+
+// RegisterDecoder registers a decoder for a format name, which is also the
+// file extension it's picked for ("yaml", "yml", "toml").
+func (l *Loader) RegisterDecoder(format string, d Decoder) {
+	l.decoders[normFormat(format)] = d
+}
+
+// DecodeJSON is the built-in JSON decoder.
+func DecodeJSON(data []byte) (any, error) {
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ParseLayer parses a "path" or "path:mode" spec. The mode suffix is only
+// split off when it names a known mode, so paths containing colons
+// (e.g. Windows drive letters) are left alone.
+func ParseLayer(spec string) (Layer, error) {
+	return ParseLayerMode(spec, merge.DefaultMergeMode)
+}
+
+// ParseLayerMode is ParseLayer with mode used for specs
+// that have neither a mode suffix nor a sidecar.
+func ParseLayerMode(spec string, mode merge.Mode) (Layer, error) {
+	return parseLayer(merge.DefaultEngine(), spec, mode)
+}
+
+func parseLayer(e *merge.Engine, spec string, mode merge.Mode) (Layer, error) {
+	if i := strings.LastIndex(spec, ":"); i > 0 {
+		if mode, found := e.Mode(spec[i+1:]); found {
+			return Layer{Path: spec[:i], Mode: mode}, nil
+		}
+	}
+
+	layer := Layer{Path: spec, Mode: mode}
+	if spec == "-" {
+		return layer, nil
+	}
+
+	sidecar, err := os.ReadFile(spec + SidecarExt)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return layer, nil
+		}
+		return Layer{}, fmt.Errorf("%s: %w", spec, err)
+	}
+	name := strings.TrimSpace(string(sidecar))
+	mode, found := e.Mode(name)
+	if !found {
+		return Layer{}, fmt.Errorf("%s: unknown merge mode %q in sidecar", spec, name)
+	}
+	layer.Mode = mode
+	return layer, nil
+}
+
+// Decode reads and decodes a single layer.
+func (l *Loader) Decode(layer Layer) (any, error) {
+	format := layer.Format
+	if format == "" {
+		format = filepath.Ext(layer.Path)
+	}
+	dec, found := l.decoders[normFormat(format)]
+	if !found {
+		return nil, fmt.Errorf("%s: no decoder for format %q", layer.Path, format)
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if layer.Path == "-" {
+		data, err = io.ReadAll(l.Stdin)
+	} else {
+		data, err = os.ReadFile(layer.Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", layer.Path, err)
+	}
+
+	tree, err := dec(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: decode: %w", layer.Path, err)
+	}
+	return tree, nil
+}
+
+// Load decodes all layers first and then merges them into orig with a
+// single Bulk call, so a broken file fails before anything is merged and
+// options scoped to the call (averages, array passes) span all files.
+// Layers are labeled with their paths.
+func (l *Loader) Load(orig any, layers ...Layer) (any, error) {
+	pairs := make([]merge.ModeDataPair, len(layers))
+	for i, layer := range layers {
+		tree, err := l.Decode(layer)
+		if err != nil {
+			return nil, err
+		}
+		pairs[i] = merge.ModeDataPair{Mode: layer.Mode, Data: tree, Label: layer.Path}
+	}
+	return l.engine().BulkWith(orig, pairs, l.Options...)
+}
+
+// Files parses specs with ParseLayer and loads them, the first file
+// becomes the base the rest are merged into whatever its mode.
+func (l *Loader) Files(specs ...string) (any, error) {
+	layers := make([]Layer, len(specs))
+	for i, spec := range specs {
+		layer, err := parseLayer(l.engine(), spec, merge.DefaultMergeMode)
+		if err != nil {
+			return nil, err
+		}
+		layers[i] = layer
+	}
+	if len(layers) == 0 {
+		return nil, nil
+	}
+	base, err := l.Decode(layers[0])
+	if err != nil {
+		return nil, err
+	}
+	return l.Load(base, layers[1:]...)
+}
+
+// Files loads specs with a default Loader.
+func Files(specs ...string) (any, error) {
+	return New().Files(specs...)
+}
+
+func (l *Loader) engine() *merge.Engine {
+	if l.Engine == nil {
+		return merge.DefaultEngine()
+	}
+	return l.Engine
+}
+
+func normFormat(format string) string {
+	return strings.ToLower(strings.TrimPrefix(format, "."))
+}
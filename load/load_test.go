@@ -0,0 +1,178 @@
+package load_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+	"github.com/4nd3r5on/go-merge/load"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestParseLayer(t *testing.T) {
+	dir := t.TempDir()
+	withSidecar := writeFile(t, dir, "b.json", `{}`)
+	writeFile(t, dir, "b.json.mode", "update\n")
+
+	cases := []struct {
+		spec string
+		want load.Layer
+	}{
+		{"a.json", load.Layer{Path: "a.json", Mode: merge.DefaultMergeMode}},
+		{"a.json:append", load.Layer{Path: "a.json", Mode: merge.ModeAppend}},
+		{`C:\conf\a.json`, load.Layer{Path: `C:\conf\a.json`, Mode: merge.DefaultMergeMode}},
+		{`C:\conf\a.json:replace`, load.Layer{Path: `C:\conf\a.json`, Mode: merge.ModeFullReplace}},
+		{withSidecar, load.Layer{Path: withSidecar, Mode: merge.ModeUpdate}},
+	}
+	for _, tc := range cases {
+		got, err := load.ParseLayer(tc.spec)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.spec, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %+v, expected %+v", tc.spec, got, tc.want)
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "base.json", `{"db": {"host": "localhost", "port": 5432}, "tags": ["a"]}`)
+	upd := writeFile(t, dir, "upd.json", `{"db": {"port": 6432, "user": "x"}}`)
+	app := writeFile(t, dir, "app.json", `{"tags": ["b"]}`)
+
+	got, err := load.Files(base, upd+":update", app+":append")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var want any
+	if err := json.Unmarshal([]byte(`{"db": {"host": "localhost", "port": 6432}, "tags": ["a", "b"]}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, expected %v", got, want)
+	}
+}
+
+func TestLoader_Decoders(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "base.json", `{"a": 1}`)
+	kv := writeFile(t, dir, "over.kv", "b=2")
+
+	l := load.New()
+	if _, err := l.Files(base, kv); err == nil || !strings.Contains(err.Error(), "over.kv") {
+		t.Errorf("expected missing decoder error naming the file, got %v", err)
+	}
+
+	l.RegisterDecoder("kv", func(data []byte) (any, error) {
+		k, v, _ := strings.Cut(string(data), "=")
+		return map[string]any{k: v}, nil
+	})
+	l.Stdin = strings.NewReader(`{"c": 3}`)
+
+	got, err := l.Load(nil,
+		load.Layer{Path: base},
+		load.Layer{Path: kv, Mode: merge.ModeInsert},
+		load.Layer{Path: "-", Format: "json", Mode: merge.ModeInsert},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"a": float64(1), "b": "2", "c": float64(3)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, expected %v", got, want)
+	}
+}
+
+func TestLoader_ErrorsNameFile(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "base.json", `{"a": {"b": 1}}`)
+	broken := writeFile(t, dir, "broken.json", `{"a": `)
+	trailing := writeFile(t, dir, "trailing.json", `{"a": {"b": 2}} garbage`)
+	mismatch := writeFile(t, dir, "mismatch.json", `{"a": [1]}`)
+	missing := filepath.Join(dir, "missing.json")
+
+	for _, p := range []string{broken, trailing, mismatch, missing} {
+		_, err := load.Files(base, p)
+		if err == nil || !strings.Contains(err.Error(), p) {
+			t.Errorf("expected error naming %s, got %v", p, err)
+		}
+	}
+
+	_, err := load.Files(base, missing)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected wrapped os.ErrNotExist, got %v", err)
+	}
+}
+
+func TestLoader_SingleBulk(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "base.json", `{"db": {"host": "a"}, "tags": ["b"]}`)
+	team := writeFile(t, dir, "team.json", `{"tags": ["c", "a"]}`)
+	ops := writeFile(t, dir, "ops.json", `{"db": {"host": "b"}}`)
+
+	passes := 0
+	l := load.New()
+	l.Options = []merge.Option{merge.WithArrayPass("tags", func(arr []any) []any {
+		passes++
+		return arr
+	}), merge.WithSortBy("tags", nil)}
+	got, err := l.Files(base, team+":append", team+":append")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if passes != 1 {
+		t.Errorf("array pass ran %d times, expected once", passes)
+	}
+	want := map[string]any{"db": map[string]any{"host": "a"}, "tags": []any{"a", "a", "b", "c", "c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, expected %v", got, want)
+	}
+
+	l.Options = []merge.Option{merge.WithProtected("db")}
+	_, err = l.Files(base, team+":append", ops+":replace")
+	var perr *merge.PolicyError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected PolicyError, got %v", err)
+	}
+	if perr.Layer != 1 || perr.Label != ops {
+		t.Errorf("got layer %d (%s), expected layer 1 (%s)", perr.Layer, perr.Label, ops)
+	}
+}
+
+func TestLoader_NullBase(t *testing.T) {
+	dir := t.TempDir()
+	null := writeFile(t, dir, "null.json", `null`)
+	arr := writeFile(t, dir, "arr.json", `[1, 2]`)
+
+	got, err := load.Files(null, arr+":difference")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != nil {
+		t.Errorf("got %v, expected the difference with a null base to stay null", got)
+	}
+
+	got, err = load.Files(null, arr+":replace")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []any{float64(1), float64(2)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, expected %v", got, want)
+	}
+}
@@ -0,0 +1,648 @@
+package load
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DecodeYAML is the built-in YAML decoder. It reads the subset of YAML
+// configuration files use: block and flow mappings and sequences, plain,
+// quoted and block (| and >) scalars, and comments. Anchors, aliases,
+// tags and multiple documents are rejected.
+//
+// Numbers are decoded as float64 and mapping keys as strings, the same
+// as DecodeJSON, so YAML and JSON layers merge with each other.
+func DecodeYAML(data []byte) (any, error) {
+	p := &yamlParser{}
+	for i, text := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		p.lines = append(p.lines, yamlLine{num: i + 1, text: text})
+	}
+	if err := p.document(); err != nil {
+		return nil, err
+	}
+
+	p.skip()
+	if p.done() {
+		return nil, nil
+	}
+	v, err := p.node(p.cur().indent())
+	if err != nil {
+		return nil, err
+	}
+	p.skip()
+	if !p.done() {
+		return nil, p.errorf("unexpected content %q", strings.TrimSpace(p.cur().text))
+	}
+	return v, nil
+}
+
+type yamlLine struct {
+	num  int
+	text string
+}
+
+func (l yamlLine) indent() int {
+	return len(l.text) - len(strings.TrimLeft(l.text, " "))
+}
+
+// content is the line without indentation and comment.
+func (l yamlLine) content() string {
+	return strings.TrimSpace(stripComment(l.text))
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (p *yamlParser) done() bool    { return p.pos >= len(p.lines) }
+func (p *yamlParser) cur() yamlLine { return p.lines[p.pos] }
+
+func (p *yamlParser) errorf(format string, args ...any) error {
+	line := 0
+	if p.pos < len(p.lines) {
+		line = p.lines[p.pos].num
+	} else if len(p.lines) > 0 {
+		line = p.lines[len(p.lines)-1].num
+	}
+	return yamlError(line, format, args...)
+}
+
+func yamlError(line int, format string, args ...any) error {
+	return fmt.Errorf("yaml: line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skip moves past blank and comment-only lines.
+func (p *yamlParser) skip() {
+	for !p.done() && p.cur().content() == "" {
+		p.pos++
+	}
+}
+
+// document drops the "---" and "..." markers of a single document.
+func (p *yamlParser) document() error {
+	var lines []yamlLine
+	for _, l := range p.lines {
+		switch c := l.content(); {
+		case strings.HasPrefix(c, "%"):
+			return yamlError(l.num, "directives are not supported")
+		case c == "---" || strings.HasPrefix(c, "--- "):
+			if hasContent(lines) {
+				return yamlError(l.num, "multiple documents are not supported")
+			}
+			lines = lines[:0]
+			if rest := strings.TrimSpace(strings.TrimPrefix(c, "---")); rest != "" {
+				lines = append(lines, yamlLine{num: l.num, text: rest})
+			}
+		case c == "...":
+			p.lines = lines
+			return nil
+		default:
+			if c != "" && strings.HasPrefix(strings.TrimLeft(l.text, " "), "\t") {
+				return yamlError(l.num, "tabs are not allowed in indentation")
+			}
+			lines = append(lines, l)
+		}
+	}
+	p.lines = lines
+	return nil
+}
+
+func hasContent(lines []yamlLine) bool {
+	for _, l := range lines {
+		if l.content() != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// node parses the block node starting at the current line,
+// which is indented by indent.
+func (p *yamlParser) node(indent int) (any, error) {
+	c := p.cur().content()
+	if isSeqItem(c) {
+		return p.sequence(indent)
+	}
+	if _, _, ok, err := splitKey(c); err != nil {
+		return nil, p.errorf("%v", err)
+	} else if ok {
+		return p.mapping(indent)
+	}
+	return p.inline(c, indent)
+}
+
+func isSeqItem(c string) bool {
+	return c == "-" || strings.HasPrefix(c, "- ")
+}
+
+func (p *yamlParser) sequence(indent int) ([]any, error) {
+	out := []any{}
+	for p.skip(); !p.done(); p.skip() {
+		l := p.cur()
+		if l.indent() != indent || !isSeqItem(l.content()) {
+			if l.indent() > indent {
+				return nil, p.errorf("bad indentation")
+			}
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(strings.TrimLeft(l.text, " "), "-"), " ")
+		if strings.TrimSpace(stripComment(rest)) == "" {
+			p.pos++
+			v, err := p.child(indent, false)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+			continue
+		}
+		// "- key: value" and "- - item" start a nested node on the
+		// same line, indented past the dash.
+		p.lines[p.pos].text = strings.Repeat(" ", len(l.text)-len(rest)) + rest
+		v, err := p.node(len(l.text) - len(rest))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (p *yamlParser) mapping(indent int) (map[string]any, error) {
+	out := map[string]any{}
+	for p.skip(); !p.done(); p.skip() {
+		l := p.cur()
+		if l.indent() != indent {
+			if l.indent() > indent {
+				return nil, p.errorf("bad indentation")
+			}
+			break
+		}
+		key, rest, ok, err := splitKey(l.content())
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if !ok {
+			return nil, p.errorf("expected a mapping key, got %q", l.content())
+		}
+		if _, dup := out[key]; dup {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+
+		var v any
+		switch {
+		case rest == "":
+			p.pos++
+			v, err = p.child(indent, true)
+		case rest[0] == '|' || rest[0] == '>':
+			v, err = p.blockScalar(rest, indent)
+		default:
+			v, err = p.inline(rest, indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		out[key] = v
+	}
+	return out, nil
+}
+
+// child parses the value of a key or sequence item left empty on its own
+// line: a node indented deeper, a sequence at the same indentation for
+// keys, or null.
+func (p *yamlParser) child(indent int, key bool) (any, error) {
+	p.skip()
+	if p.done() {
+		return nil, nil
+	}
+	l := p.cur()
+	if l.indent() > indent || key && l.indent() == indent && isSeqItem(l.content()) {
+		return p.node(l.indent())
+	}
+	return nil, nil
+}
+
+// inline parses a scalar or flow collection starting on the current line.
+// Flow collections may continue on the following lines.
+func (p *yamlParser) inline(c string, indent int) (any, error) {
+	if c[0] == '&' || c[0] == '*' || c[0] == '!' {
+		return nil, p.errorf("anchors, aliases and tags are not supported")
+	}
+	if c[0] != '[' && c[0] != '{' {
+		v, err := scalar(c)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		p.pos++
+		if !p.done() {
+			if next := p.cur(); next.content() != "" && next.indent() > indent {
+				return nil, p.errorf("multi-line plain scalars are not supported, quote the value")
+			}
+		}
+		return v, nil
+	}
+
+	src := c
+	start := p.pos
+	for {
+		f := &flowParser{src: src}
+		v, err := f.value()
+		if err == nil {
+			f.space()
+			if f.pos != len(f.src) {
+				return nil, p.errorf("unexpected %q after flow collection", f.src[f.pos:])
+			}
+			p.pos++
+			return v, nil
+		}
+		if !errors.Is(err, errFlowEOF) {
+			return nil, p.errorf("%v", err)
+		}
+		p.pos++
+		if p.done() {
+			p.pos = start
+			return nil, p.errorf("unterminated flow collection")
+		}
+		src += " " + p.cur().content()
+	}
+}
+
+// blockScalar parses a literal (|) or folded (>) scalar whose header is
+// rest, with its lines indented past indent.
+func (p *yamlParser) blockScalar(header string, indent int) (string, error) {
+	header = strings.TrimSpace(stripComment(header))
+	style, chomp := header[0], header[1:]
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return "", p.errorf("unsupported block scalar header %q", header)
+	}
+	p.pos++
+
+	var lines []string
+	blockIndent := -1
+	for ; !p.done(); p.pos++ {
+		l := p.cur()
+		if strings.TrimSpace(l.text) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if blockIndent < 0 {
+			blockIndent = l.indent()
+		}
+		if l.indent() <= indent || l.indent() < blockIndent {
+			break
+		}
+		lines = append(lines, l.text[blockIndent:])
+	}
+
+	trailing := 0
+	for trailing < len(lines) && lines[len(lines)-1-trailing] == "" {
+		trailing++
+	}
+	body := lines[:len(lines)-trailing]
+	// blank lines past the block belong to whatever follows it
+	p.pos -= trailing
+
+	var s string
+	if style == '|' {
+		s = strings.Join(body, "\n")
+	} else {
+		var b strings.Builder
+		for i, line := range body {
+			// line breaks fold into spaces, blank lines into newlines
+			switch {
+			case i == 0 || line != "" && body[i-1] == "":
+			case line == "":
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(' ')
+			}
+			b.WriteString(line)
+		}
+		s = b.String()
+	}
+
+	switch {
+	case len(body) == 0:
+		return "", nil
+	case chomp == "-":
+		return s, nil
+	case chomp == "+":
+		return s + strings.Repeat("\n", trailing+1), nil
+	}
+	return s + "\n", nil
+}
+
+// splitKey splits a "key: value" line, ok is false when c isn't one.
+func splitKey(c string) (key, rest string, ok bool, err error) {
+	if c == "" || c[0] == '[' || c[0] == '{' || isSeqItem(c) {
+		return "", "", false, nil
+	}
+	if c[0] == '"' || c[0] == '\'' {
+		end, err := quotedEnd(c)
+		if err != nil {
+			return "", "", false, err
+		}
+		after := strings.TrimLeft(c[end:], " ")
+		if !strings.HasPrefix(after, ":") || len(after) > 1 && after[1] != ' ' {
+			return "", "", false, nil
+		}
+		key, err := unquote(c[:end])
+		return key, strings.TrimSpace(after[1:]), err == nil, err
+	}
+	for i := 0; i < len(c); i++ {
+		if c[i] == ':' && (i+1 == len(c) || c[i+1] == ' ') {
+			key := strings.TrimSpace(c[:i])
+			if key == "?" || strings.HasPrefix(key, "? ") {
+				return "", "", false, errors.New("complex keys are not supported")
+			}
+			return key, strings.TrimSpace(c[i+1:]), true, nil
+		}
+	}
+	return "", "", false, nil
+}
+
+// stripComment cuts a "#" comment that isn't inside quotes.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" \t[{,:-", rune(s[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// quotedEnd returns the index after the quoted scalar s starts with.
+func quotedEnd(s string) (int, error) {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case q == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated quoted scalar %s", s)
+}
+
+var yamlEscapes = strings.NewReplacer(`\0`, "\x00", `\a`, "\a", `\b`, "\b", `\t`, "\t", `\n`, "\n",
+	`\v`, "\v", `\f`, "\f", `\r`, "\r", `\e`, "\x1b", `\ `, " ", `\"`, `"`, `\/`, "/", `\\`, `\`,
+	`\N`, "\u0085", `\_`, " ", `\L`, " ", `\P`, " ")
+
+func unquote(s string) (string, error) {
+	body := s[1 : len(s)-1]
+	if s[0] == '\'' {
+		return strings.ReplaceAll(body, "''", "'"), nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' {
+			b.WriteByte(body[i])
+			continue
+		}
+		if i+1 == len(body) {
+			return "", fmt.Errorf("bad escape in %s", s)
+		}
+		size := map[byte]int{'x': 2, 'u': 4, 'U': 8}[body[i+1]]
+		if size == 0 {
+			esc := yamlEscapes.Replace(body[i : i+2])
+			if esc == body[i:i+2] {
+				return "", fmt.Errorf("bad escape %s in %s", esc, s)
+			}
+			b.WriteString(esc)
+			i++
+			continue
+		}
+		if i+2+size > len(body) {
+			return "", fmt.Errorf("bad escape in %s", s)
+		}
+		r, err := strconv.ParseUint(body[i+2:i+2+size], 16, 32)
+		if err != nil {
+			return "", fmt.Errorf("bad escape in %s", s)
+		}
+		b.WriteRune(rune(r))
+		i += 1 + size
+	}
+	return b.String(), nil
+}
+
+// scalar resolves a plain or quoted scalar to null, a bool, a float64 or
+// a string, following the YAML 1.2 core schema.
+func scalar(s string) (any, error) {
+	if s[0] == '"' || s[0] == '\'' {
+		end, err := quotedEnd(s)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(s[end:]) != "" {
+			return nil, fmt.Errorf("unexpected %q after quoted scalar", s[end:])
+		}
+		return unquote(s[:end])
+	}
+	if strings.ContainsAny(s[:1], "@`%") {
+		return nil, fmt.Errorf("plain scalar can't start with %q", s[:1])
+	}
+
+	switch s {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF":
+		return math.Inf(1), nil
+	case "-.inf", "-.Inf", "-.INF":
+		return math.Inf(-1), nil
+	case ".nan", ".NaN", ".NAN":
+		return math.NaN(), nil
+	}
+	if n, ok := number(s); ok {
+		return n, nil
+	}
+	return s, nil
+}
+
+func number(s string) (float64, bool) {
+	switch {
+	case strings.HasPrefix(s, "0x"):
+		n, err := strconv.ParseUint(s[2:], 16, 64)
+		return float64(n), err == nil
+	case strings.HasPrefix(s, "0o"):
+		n, err := strconv.ParseUint(s[2:], 8, 64)
+		return float64(n), err == nil
+	}
+	// ParseFloat also takes "inf", "0x1p3" and underscores, which YAML
+	// reads as strings
+	for i := 0; i < len(s); i++ {
+		if !strings.ContainsRune("0123456789+-.eE", rune(s[i])) {
+			return 0, false
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+var errFlowEOF = errors.New("unexpected end of flow collection")
+
+// flowParser parses flow collections ([a, b], {k: v}) from a single string.
+type flowParser struct {
+	src string
+	pos int
+}
+
+func (f *flowParser) space() {
+	for f.pos < len(f.src) && f.src[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+func (f *flowParser) value() (any, error) {
+	f.space()
+	if f.pos == len(f.src) {
+		return nil, errFlowEOF
+	}
+	switch f.src[f.pos] {
+	case '[':
+		return f.sequence()
+	case '{':
+		return f.mapping()
+	case '&', '*', '!':
+		return nil, errors.New("anchors, aliases and tags are not supported")
+	}
+	s, quoted, err := f.scalarText(false)
+	if err != nil {
+		return nil, err
+	}
+	if quoted {
+		return unquote(s)
+	}
+	return scalar(s)
+}
+
+// scalarText returns the raw text of the scalar at pos, up to the next
+// flow indicator. Keys also end at ':'.
+func (f *flowParser) scalarText(key bool) (string, bool, error) {
+	start := f.pos
+	if c := f.src[f.pos]; c == '"' || c == '\'' {
+		end, err := quotedEnd(f.src[f.pos:])
+		if err != nil {
+			return "", false, errFlowEOF
+		}
+		f.pos += end
+		return f.src[start:f.pos], true, nil
+	}
+	for ; f.pos < len(f.src); f.pos++ {
+		c := f.src[f.pos]
+		if c == ',' || c == ']' || c == '}' || key && c == ':' {
+			break
+		}
+		if c == ':' && (f.pos+1 == len(f.src) || strings.ContainsRune(" ,]}", rune(f.src[f.pos+1]))) {
+			break
+		}
+	}
+	return strings.TrimSpace(f.src[start:f.pos]), false, nil
+}
+
+func (f *flowParser) sequence() ([]any, error) {
+	f.pos++
+	out := []any{}
+	for {
+		f.space()
+		if f.pos == len(f.src) {
+			return nil, errFlowEOF
+		}
+		if f.src[f.pos] == ']' {
+			f.pos++
+			return out, nil
+		}
+		v, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+		if err := f.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *flowParser) mapping() (map[string]any, error) {
+	f.pos++
+	out := map[string]any{}
+	for {
+		f.space()
+		if f.pos == len(f.src) {
+			return nil, errFlowEOF
+		}
+		if f.src[f.pos] == '}' {
+			f.pos++
+			return out, nil
+		}
+
+		raw, quoted, err := f.scalarText(true)
+		if err != nil {
+			return nil, err
+		}
+		key := raw
+		if quoted {
+			if key, err = unquote(raw); err != nil {
+				return nil, err
+			}
+		}
+		if _, dup := out[key]; dup {
+			return nil, fmt.Errorf("duplicate key %q", key)
+		}
+
+		f.space()
+		var v any
+		if f.pos < len(f.src) && f.src[f.pos] == ':' {
+			f.pos++
+			f.space()
+			if f.pos < len(f.src) && (f.src[f.pos] == ',' || f.src[f.pos] == '}') {
+				v = nil
+			} else if v, err = f.value(); err != nil {
+				return nil, err
+			}
+		}
+		out[key] = v
+		if err := f.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// separator consumes the ',' between entries, leaving the closing
+// bracket for the caller.
+func (f *flowParser) separator(closing byte) error {
+	f.space()
+	if f.pos == len(f.src) {
+		return errFlowEOF
+	}
+	switch f.src[f.pos] {
+	case ',':
+		f.pos++
+		return nil
+	case closing:
+		return nil
+	}
+	return fmt.Errorf("expected ',' or %q in flow collection, got %q", closing, f.src[f.pos:])
+}
@@ -0,0 +1,94 @@
+package load_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge/load"
+)
+
+func TestDecodeYAML(t *testing.T) {
+	src := `# service config
+---
+db:
+  host: "db#1" # primary
+  port: 5432
+  opts: {ssl: true, ca: 'it''s', retries: [1, 2,
+    3]}
+tags:
+- a
+- name: b
+  weight: 0x10
+- - nested
+  - ~
+unset:
+motd: |
+  hello
+    world
+
+folded: >-
+  one
+  two
+
+  three
+`
+	want := map[string]any{
+		"db": map[string]any{
+			"host": "db#1",
+			"port": float64(5432),
+			"opts": map[string]any{"ssl": true, "ca": "it's", "retries": []any{float64(1), float64(2), float64(3)}},
+		},
+		"tags": []any{
+			"a",
+			map[string]any{"name": "b", "weight": float64(16)},
+			[]any{"nested", nil},
+		},
+		"unset":  nil,
+		"motd":   "hello\n  world\n",
+		"folded": "one two\nthree",
+	}
+
+	got, err := load.DecodeYAML([]byte(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, expected %v", got, want)
+	}
+}
+
+func TestDecodeYAML_Errors(t *testing.T) {
+	cases := []struct {
+		src, msg string
+	}{
+		{"a: 1\na: 2", "line 2: duplicate key"},
+		{"a: &x 1\nb: *x", "line 1: anchors, aliases and tags"},
+		{"a:\n\tb: 1", "line 2: tabs"},
+		{"a: [1, 2", "unterminated flow collection"},
+		{"a: 1\n---\nb: 2", "line 2: multiple documents"},
+		{"a: b\n  c", "line 2: multi-line plain scalars"},
+		{"a:\n  b: 1\n c: 2", "line 3: bad indentation"},
+	}
+	for _, tc := range cases {
+		_, err := load.DecodeYAML([]byte(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.msg) {
+			t.Errorf("%q: expected error containing %q, got %v", tc.src, tc.msg, err)
+		}
+	}
+}
+
+func TestFiles_YAML(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "base.json", `{"db": {"host": "a", "port": 1}, "tags": ["x"]}`)
+	over := writeFile(t, dir, "over.yml", "db:\n  port: 2\n  user: u\n")
+
+	got, err := load.Files(base, over+":update")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"db": map[string]any{"host": "a", "port": float64(2)}, "tags": []any{"x"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, expected %v", got, want)
+	}
+}
@@ -0,0 +1,254 @@
+package merge_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestLWWMode(t *testing.T) {
+	s := func(v any, time uint64, replica string) merge.Stamped {
+		return merge.Stamped{Value: v, Time: time, Replica: replica}
+	}
+	cases := []TestCase{
+		{
+			Name:     "newer wins",
+			Mode:     merge.ModeLWW,
+			Original: M("port", s(80, 2, "a"), "host", s("x", 5, "a")),
+			Merge:    M("port", s(8080, 3, "b"), "host", s("y", 4, "b")),
+			Expected: M("port", s(8080, 3, "b"), "host", s("x", 5, "a")),
+		},
+		{
+			Name:     "replica breaks ties",
+			Mode:     merge.ModeLWW,
+			Original: M("port", s(80, 3, "b")),
+			Merge:    M("port", s(8080, 3, "a")),
+			Expected: M("port", s(80, 3, "b")),
+		},
+		{
+			Name:     "keys are unioned",
+			Mode:     merge.ModeLWW,
+			Original: M("db", M("host", s("x", 1, "a"))),
+			Merge:    M("db", M("port", s(5432, 1, "b"))),
+			Expected: M("db", M("host", s("x", 1, "a"), "port", s(5432, 1, "b"))),
+		},
+		{
+			Name:     "tombstone wins over older write",
+			Mode:     merge.ModeLWW,
+			Original: M("debug", s(true, 1, "a")),
+			Merge:    M("debug", merge.Tombstone(2, "b")),
+			Expected: M("debug", merge.Tombstone(2, "b")),
+		},
+		{
+			Name:     "older tombstone loses",
+			Mode:     merge.ModeLWW,
+			Original: M("debug", s(true, 3, "a")),
+			Merge:    M("debug", merge.Tombstone(2, "b")),
+			Expected: M("debug", s(true, 3, "a")),
+		},
+		{
+			Name:     "arrays element by element",
+			Mode:     merge.ModeLWW,
+			Original: []any{s(1, 1, "a"), s(2, 5, "a")},
+			Merge:    []any{s(10, 2, "b"), s(20, 2, "b"), s(30, 2, "b")},
+			Expected: []any{s(10, 2, "b"), s(2, 5, "a"), s(30, 2, "b")},
+		},
+		{
+			Name:     "stamped wins over plain",
+			Mode:     merge.ModeLWW,
+			Original: M("a", s(1, 0, "a")),
+			Merge:    M("a", 2),
+			Expected: M("a", s(1, 0, "a")),
+		},
+		{
+			Name:     "stamped array as a leaf",
+			Mode:     merge.ModeLWW,
+			Original: M("tags", s([]any{"a", "b"}, 1, "a")),
+			Merge:    M("tags", s([]any{"c"}, 2, "b")),
+			Expected: M("tags", s([]any{"c"}, 2, "b")),
+		},
+	}
+
+	TableTest(t, cases)
+}
+
+func TestLWWMode_Convergence(t *testing.T) {
+	replicas := []func() any{
+		func() any {
+			return merge.Stamp(M("db", M("host", "x", "port", 5432), "tags", []any{"a"}), 1, "a")
+		},
+		func() any {
+			return merge.Stamp(M("db", M("host", "y"), "debug", true), 2, "b")
+		},
+		func() any {
+			return M("db", M("port", merge.Tombstone(3, "c")), "debug", merge.Stamped{Value: false, Time: 2, Replica: "c"},
+				"tags", []any{merge.Stamped{Value: "z", Time: 1, Replica: "c"}, merge.Stamped{Value: "w", Time: 1, Replica: "c"}})
+		},
+	}
+	mustMerge := func(a, b any) any {
+		t.Helper()
+		res, err := merge.Data(merge.ModeLWW, a, b)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return res
+	}
+	r := func(i int) any { return replicas[i]() }
+
+	for i := range replicas {
+		if res := mustMerge(r(i), r(i)); !reflect.DeepEqual(res, r(i)) {
+			t.Errorf("replica %d: merge with itself changed it: %v", i, toJSON(res))
+		}
+		for j := range replicas {
+			ab, ba := mustMerge(r(i), r(j)), mustMerge(r(j), r(i))
+			if !reflect.DeepEqual(ab, ba) {
+				t.Errorf("%d,%d: not commutative: %v != %v", i, j, toJSON(ab), toJSON(ba))
+			}
+		}
+	}
+
+	left := mustMerge(mustMerge(r(0), r(1)), r(2))
+	right := mustMerge(r(0), mustMerge(r(1), r(2)))
+	if !reflect.DeepEqual(left, right) {
+		t.Errorf("not associative: %v != %v", toJSON(left), toJSON(right))
+	}
+
+	expected := M("db", M("host", "y"), "debug", false, "tags", []any{"z", "w"})
+	if res := merge.Unstamp(left); !reflect.DeepEqual(res, expected) {
+		t.Errorf("got %v, expected %v", toJSON(res), toJSON(expected))
+	}
+}
+
+func TestLWWMode_StampedOverContainer(t *testing.T) {
+	tree := merge.Stamp(M("db", M("host", "x")), 1, "a")
+	deleted := M("db", merge.Tombstone(2, "b"))
+
+	for _, pair := range [][2]any{{tree, deleted}, {deleted, tree}} {
+		res, err := merge.Data(merge.ModeLWW, pair[0], pair[1], merge.WithCopy())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(res, deleted) {
+			t.Errorf("got %v, expected %v", res, deleted)
+		}
+	}
+}
+
+func TestLWWMode_StampedOverRoot(t *testing.T) {
+	tree := func() any { return merge.Stamp(M("db", M("host", "x")), 1, "b") }
+	reset := merge.Stamped{Value: "reset", Time: 5, Replica: "a"}
+
+	for _, pair := range [][2]any{{tree(), reset}, {reset, tree()}} {
+		res, err := merge.Data(merge.ModeLWW, pair[0], pair[1])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(res, reset) {
+			t.Errorf("got %v, expected %v", res, reset)
+		}
+	}
+}
+
+func TestLWWMode_StampedOverElementNullPolicy(t *testing.T) {
+	orig := func() any {
+		return []any{merge.Stamp(M("k", "v"), 1, "b"), merge.Stamped{Value: 1, Time: 1, Replica: "b"}}
+	}
+	data := func() any {
+		return []any{merge.Stamped{Value: "z", Time: 0, Replica: "a"}, merge.Stamped{Value: 2, Time: 2, Replica: "a"}}
+	}
+	want := []any{
+		merge.Stamped{Value: "z", Time: 0, Replica: "a", Kept: merge.Stamp(M("k", "v"), 1, "b")},
+		merge.Stamped{Value: 2, Time: 2, Replica: "a"},
+	}
+
+	for _, p := range []merge.NullPolicy{merge.NullDelete, merge.NullSet} {
+		for _, pair := range [][2]any{{orig(), data()}, {data(), orig()}} {
+			res, err := merge.Data(merge.ModeLWW, pair[0], pair[1], merge.WithNullPolicy(p))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(res, want) {
+				t.Errorf("null policy %v: got %v, expected %v", p, res, want)
+			}
+		}
+	}
+}
+
+func TestLWWMode_ContainerConvergence(t *testing.T) {
+	replicas := []func() any{
+		func() any {
+			return merge.Stamp(M("db", M("host", "x", "port", 5432), "tags", []any{"a", "b"}, "cache", M("size", 1)), 1, "a")
+		},
+		func() any {
+			return M("db", merge.Tombstone(2, "b"), "tags", merge.Tombstone(2, "b"))
+		},
+		func() any {
+			return merge.Stamp(M("db", M("user", "u"), "tags", []any{"c"}), 3, "c")
+		},
+		func() any {
+			return merge.Stamp(M("db", M("port", 1)), 1, "d")
+		},
+		func() any {
+			return M("cache", merge.Stamped{Value: "off", Time: 2, Replica: "e"})
+		},
+		func() any {
+			// replaces the whole tree, only the writes after it are kept
+			return merge.Stamped{Value: "reset", Time: 2, Replica: "d0"}
+		},
+	}
+	mustMerge := func(a, b any) any {
+		t.Helper()
+		res, err := merge.Data(merge.ModeLWW, a, b)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return res
+	}
+	r := func(i int) any { return replicas[i]() }
+
+	for i := range replicas {
+		for j := range replicas {
+			ab, ba := mustMerge(r(i), r(j)), mustMerge(r(j), r(i))
+			if !reflect.DeepEqual(ab, ba) {
+				t.Errorf("%d,%d: not commutative: %v != %v", i, j, ab, ba)
+			}
+			if again := mustMerge(mustMerge(r(i), r(j)), r(j)); !reflect.DeepEqual(again, ab) {
+				t.Errorf("%d,%d: merging twice changed the result: %v != %v", i, j, again, ab)
+			}
+		}
+	}
+
+	// every order of merging all replicas one by one
+	var want any
+	var permute func(order []int, left []int)
+	permute = func(order, left []int) {
+		if len(left) == 0 {
+			res := r(order[0])
+			for _, i := range order[1:] {
+				res = mustMerge(res, r(i))
+			}
+			if want == nil {
+				want = res
+			} else if !reflect.DeepEqual(res, want) {
+				t.Errorf("order %v: got %v, expected %v", order, res, want)
+			}
+			return
+		}
+		for k, i := range left {
+			rest := append(append([]int{}, left[:k]...), left[k+1:]...)
+			permute(append(order, i), rest)
+		}
+	}
+	permute(nil, []int{0, 1, 2, 3, 4, 5})
+
+	split := mustMerge(mustMerge(r(0), r(3)), mustMerge(mustMerge(r(1), r(4)), mustMerge(r(5), r(2))))
+	if !reflect.DeepEqual(split, want) {
+		t.Errorf("not associative: %v != %v", split, want)
+	}
+
+	expected := M("db", M("user", "u"), "tags", []any{"c"}, "cache", "off")
+	if res := merge.Unstamp(want); !reflect.DeepEqual(res, expected) {
+		t.Errorf("got %v, expected %v", toJSON(res), toJSON(expected))
+	}
+}
@@ -2,6 +2,7 @@ package merge
 
 import (
 	"fmt"
+	"reflect"
 )
 
 type Mode int
@@ -12,58 +13,65 @@ const (
 	ModeInsert
 	ModeAppend
 	ModeUpdate
+	ModeSet
+	ModePrepend
+	ModePrependUnique
+	ModeUnion
+	ModeIntersect
+	ModeDifference
+	ModeSymmetricDifference
+	ModeSplice
+	ModeSum
+	ModeMax
+	ModeMin
+	ModeAvg
+	ModeLWW
 	DefaultMergersCount
 
 	DefaultMergeMode = ModeInsert
 )
 
 type Merger interface {
-	MergeMap(next Merger, path []string, orig, mergeData map[string]any) (map[string]any, error)
-	MergeArray(next Merger, path []string, orig, mergeData []any) ([]any, error)
-	MergeSparseArray(next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error)
-	MergeIntMap(next Merger, path []string, orig, mergeData map[int]any) (map[int]any, error)
-	MergePrimitive(next Merger, path []string, orig, mergeData any) (any, error)
+	MergeMap(ctx *Context, next Merger, path []string, orig, mergeData map[string]any) (map[string]any, error)
+	MergeArray(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error)
+	MergeSparseArray(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error)
+	MergeIntMap(ctx *Context, next Merger, path []string, orig, mergeData map[int]any) (map[int]any, error)
+	MergePrimitive(ctx *Context, next Merger, path []string, orig, mergeData any) (any, error)
+}
+
+// AnyMapMerger is implemented by Mergers that handle maps keyed by any
+// other comparable type (int64, uint, named string types, structs...).
+// Both maps are copies in map[any]any form; the result is written back
+// into the original map. Merging such maps with a Merger without it
+// fails, see MergeAnyMap for forwarding it from middlewares.
+type AnyMapMerger interface {
+	MergeAnyMap(ctx *Context, next Merger, path []string, orig, mergeData map[any]any) (map[any]any, error)
 }
 
 type ModeDataPair struct {
 	Mode Mode
 	Data any
+	// Label identifies who contributed the layer in errors
+	// and is passed to policies.
+	Label string
+	// Policy decides which changes the layer may make, see Policy.
+	Policy Policy
 }
 
-var ModeMap = map[string]Mode{
-	"replace":   ModeFullReplace,
-	"replace_p": ModePartialReplace,
-	"insert":    ModeInsert,
-	"append":    ModeAppend,
-	"update":    ModeUpdate,
+// Bulk merges every layer into orig in order using the default engine.
+func Bulk(orig any, layers ...ModeDataPair) (any, error) {
+	return defaultEngine.Bulk(orig, layers...)
 }
 
-var Mergers = map[Mode]Merger{
-	ModeFullReplace:    &ReplaceMerger{Mode: ModeFullReplace, Conf: ReplaceMode{Partial: false}},
-	ModePartialReplace: &ReplaceMerger{Mode: ModePartialReplace, Conf: ReplaceMode{Partial: true}},
-	ModeInsert:         &InsertMerger{Mode: ModeInsert},
-	ModeAppend:         &InsertMerger{Mode: ModeAppend, Conf: InsertMode{Append: true}},
-	ModeUpdate:         &UpdateMerger{Mode: ModeUpdate},
+// BulkWith is Bulk with options, which apply to all layers.
+func BulkWith(orig any, layers []ModeDataPair, opts ...Option) (any, error) {
+	return defaultEngine.BulkWith(orig, layers, opts...)
 }
 
-func Bulk(orig any, mergeData ...ModeDataPair) (any, error) {
-	var err error
-	for _, mergePart := range mergeData {
-		orig, err = Data(mergePart.Mode, orig, mergePart.Data)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return orig, nil
-}
-
-// Data recursively merges `mergeData` into `orig`.
+// Data recursively merges `mergeData` into `orig` using the default engine.
 // Returns the resulting merged structure or an error on invalid mode or type mismatch.
-func Data(mode Mode, orig, mergeData any) (any, error) {
-	if merger, found := Mergers[mode]; found {
-		return UseMerger(merger, nil, orig, mergeData)
-	}
-	return nil, fmt.Errorf("merger mode %q doesn't exist", mode)
+func Data(mode Mode, orig, mergeData any, opts ...Option) (any, error) {
+	return defaultEngine.Data(mode, orig, mergeData, opts...)
 }
 
 func formatPath(p []string) any {
@@ -78,11 +86,45 @@ func typeMismatch(p []string, expected string, got any) error {
 		formatPath(p), expected, got)
 }
 
-func UseMerger(m Merger, path []string, orig, mergeData any) (any, error) {
+func UseMerger(ctx *Context, m Merger, path []string, orig, mergeData any) (any, error) {
+	if ctx == nil {
+		ctx = NewContext(nil)
+	}
 	if path == nil {
 		path = make([]string, 0)
 	}
 
+	if state := ctx.filter(path); state != filterAllow {
+		return ctx.applyFilter(state, path, orig, mergeData, func() (any, error) {
+			return mergeNode(ctx, m, path, orig, mergeData)
+		})
+	}
+	return mergeNode(ctx, m, path, orig, mergeData)
+}
+
+func mergeNode(ctx *Context, m Merger, path []string, orig, mergeData any) (any, error) {
+	if res, handled, err := ctx.customize(path, orig, mergeData); err != nil {
+		return ctx.fail(orig, err)
+	} else if handled {
+		return res, nil
+	}
+
+	if mergeData == nil {
+		if res, handled := ctx.applyNull(orig); handled {
+			return res, nil
+		}
+	}
+
+	// Stamped values are leaves, ModeLWW joins them with the container
+	// they overwrite
+	if _, stamped := mergeData.(Stamped); stamped && isContainer(orig) {
+		res, err := m.MergePrimitive(ctx, m, path, orig, mergeData)
+		if err != nil {
+			return ctx.fail(orig, fmt.Errorf("primitive merge failed at %v: %w", formatPath(path), err))
+		}
+		return res, nil
+	}
+
 	switch o := orig.(type) {
 	case map[string]any:
 		if mergeData == nil {
@@ -90,11 +132,11 @@ func UseMerger(m Merger, path []string, orig, mergeData any) (any, error) {
 		}
 		md, ok := mergeData.(map[string]any)
 		if !ok {
-			return nil, typeMismatch(path, "map[string]any", mergeData)
+			return ctx.fail(orig, typeMismatch(path, "map[string]any", mergeData))
 		}
-		res, err := m.MergeMap(m, path, o, md)
+		res, err := m.MergeMap(ctx, m, path, o, md)
 		if err != nil {
-			return nil, fmt.Errorf("map merge failed at %v: %w", formatPath(path), err)
+			return ctx.fail(orig, fmt.Errorf("map merge failed at %v: %w", formatPath(path), err))
 		}
 		return res, nil
 
@@ -105,21 +147,21 @@ func UseMerger(m Merger, path []string, orig, mergeData any) (any, error) {
 
 		switch md := mergeData.(type) {
 		case []any:
-			res, err := m.MergeArray(m, path, o, md)
+			res, err := m.MergeArray(ctx, m, path, o, md)
 			if err != nil {
-				return nil, fmt.Errorf("array merge failed at %v: %w", formatPath(path), err)
+				return ctx.fail(orig, fmt.Errorf("array merge failed at %v: %w", formatPath(path), err))
 			}
 			return res, nil
 
 		case map[int]any:
-			res, err := m.MergeSparseArray(m, path, o, md)
+			res, err := m.MergeSparseArray(ctx, m, path, o, md)
 			if err != nil {
-				return nil, fmt.Errorf("sparse array merge failed at %v: %w", formatPath(path), err)
+				return ctx.fail(orig, fmt.Errorf("sparse array merge failed at %v: %w", formatPath(path), err))
 			}
 			return res, nil
 
 		default:
-			return nil, typeMismatch(path, "[]any or map[int]any", mergeData)
+			return ctx.fail(orig, typeMismatch(path, "[]any or map[int]any", mergeData))
 		}
 
 	case map[int]any:
@@ -128,31 +170,54 @@ func UseMerger(m Merger, path []string, orig, mergeData any) (any, error) {
 		}
 		md, ok := mergeData.(map[int]any)
 		if !ok {
-			return nil, typeMismatch(path, "map[int]any", mergeData)
+			return ctx.fail(orig, typeMismatch(path, "map[int]any", mergeData))
 		}
-		res, err := m.MergeIntMap(m, path, o, md)
+		res, err := m.MergeIntMap(ctx, m, path, o, md)
 		if err != nil {
-			return nil, fmt.Errorf("int map merge failed at %v: %w", formatPath(path), err)
+			return ctx.fail(orig, fmt.Errorf("int map merge failed at %v: %w", formatPath(path), err))
 		}
 		return res, nil
 
 	default:
-		res, err := m.MergePrimitive(m, path, orig, mergeData)
+		if v := reflect.ValueOf(orig); v.Kind() == reflect.Map {
+			return useAnyMap(ctx, m, path, v, mergeData)
+		}
+
+		if ctx.opts.Strict && orig != nil && mergeData != nil &&
+			reflect.TypeOf(orig) != reflect.TypeOf(mergeData) {
+			return ctx.fail(orig, typeMismatch(path, fmt.Sprintf("%T", orig), mergeData))
+		}
+
+		res, err := m.MergePrimitive(ctx, m, path, orig, mergeData)
 		if err != nil {
-			return nil, fmt.Errorf("primitive merge failed at %v: %w", formatPath(path), err)
+			return ctx.fail(orig, fmt.Errorf("primitive merge failed at %v: %w", formatPath(path), err))
 		}
 		return res, nil
 	}
 }
 
-func init() {
-	orig := map[string]any{}
+// strategyMerger applies the WithArrayStrategy and WithPrimitiveStrategy
+// overrides in place of the merger registered for a mode. Engine.Merger
+// wraps it with the middlewares, so they see overridden nodes like any
+// other.
+type strategyMerger struct {
+	Merger
+}
+
+func (m strategyMerger) MergeArray(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	if ctx.opts.Arrays != nil {
+		return ctx.opts.Arrays(ctx, next, path, orig, mergeData)
+	}
+	return m.Merger.MergeArray(ctx, next, path, orig, mergeData)
+}
+
+func (m strategyMerger) MergePrimitive(ctx *Context, next Merger, path []string, orig, mergeData any) (any, error) {
+	if s := ctx.primitiveStrategy(path); s != nil {
+		return s(ctx, next, path, orig, mergeData)
+	}
+	return m.Merger.MergePrimitive(ctx, next, path, orig, mergeData)
+}
 
-	Bulk(orig,
-		ModeDataPair{
-			Mode: ModeAppend,
-		},
-		ModeDataPair{},
-		ModeDataPair{},
-	)
+func (m strategyMerger) MergeAnyMap(ctx *Context, next Merger, path []string, orig, mergeData map[any]any) (map[any]any, error) {
+	return MergeAnyMap(ctx, m.Merger, next, path, orig, mergeData)
 }
@@ -16,6 +16,7 @@ type TestCase struct {
 	Mode      merge.Mode
 	Original  any
 	Merge     any
+	Options   []merge.Option
 	Expected  any
 	ShouldErr bool
 	ErrMsg    string
@@ -25,7 +26,7 @@ type TestCase struct {
 func RunTestCase(t *testing.T, tc TestCase) {
 	t.Helper()
 
-	result, err := merge.Data(tc.Mode, tc.Original, tc.Merge)
+	result, err := merge.Data(tc.Mode, tc.Original, tc.Merge, tc.Options...)
 
 	if tc.ShouldErr {
 		if err == nil {
@@ -0,0 +1,96 @@
+package merge
+
+import "fmt"
+
+// Middleware decorates a Merger. Decorated mergers must pass the next
+// Merger they receive on to the wrapped one unchanged: built-in mergers
+// recurse through it, so nested values go through the whole chain again.
+type Middleware func(Merger) Merger
+
+// Chain wraps base with middlewares, the first one being the outermost.
+func Chain(base Merger, mws ...Middleware) Merger {
+	for i := len(mws) - 1; i >= 0; i-- {
+		base = mws[i](base)
+	}
+	return base
+}
+
+// AroundFunc is called for every node merged by the wrapped Merger.
+// Calling merge runs the wrapped Merger; not calling it short-circuits the
+// node. The result must keep the type of orig for containers.
+type AroundFunc func(ctx *Context, path []string, orig, mergeData any, merge func() (any, error)) (any, error)
+
+// Around turns a single hook into a Middleware, which covers logging,
+// metrics, validation and path filters without implementing every Merger
+// method:
+//
+//	logging := merge.Around(func(ctx *merge.Context, path []string, orig, data any, next func() (any, error)) (any, error) {
+//		res, err := next()
+//		log.Printf("%v: %v + %v = %v", merge.Path(path), orig, data, res)
+//		return res, err
+//	})
+func Around(fn AroundFunc) Middleware {
+	return func(base Merger) Merger {
+		return &aroundMerger{base: base, fn: fn}
+	}
+}
+
+type aroundMerger struct {
+	base Merger
+	fn   AroundFunc
+}
+
+func around[T any](m *aroundMerger, ctx *Context, path []string, orig T, mergeData any, merge func() (T, error)) (T, error) {
+	res, err := m.fn(ctx, path, orig, mergeData, func() (any, error) {
+		return merge()
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if res == nil {
+		var zero T
+		return zero, nil
+	}
+	out, ok := res.(T)
+	if !ok {
+		return out, fmt.Errorf("middleware returned %T, expected %T", res, out)
+	}
+	return out, nil
+}
+
+func (m *aroundMerger) MergeMap(ctx *Context, next Merger, path []string, orig, mergeData map[string]any) (map[string]any, error) {
+	return around(m, ctx, path, orig, mergeData, func() (map[string]any, error) {
+		return m.base.MergeMap(ctx, next, path, orig, mergeData)
+	})
+}
+
+func (m *aroundMerger) MergeArray(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	return around(m, ctx, path, orig, mergeData, func() ([]any, error) {
+		return m.base.MergeArray(ctx, next, path, orig, mergeData)
+	})
+}
+
+func (m *aroundMerger) MergeSparseArray(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	return around(m, ctx, path, orig, mergeData, func() ([]any, error) {
+		return m.base.MergeSparseArray(ctx, next, path, orig, mergeData)
+	})
+}
+
+func (m *aroundMerger) MergeIntMap(ctx *Context, next Merger, path []string, orig, mergeData map[int]any) (map[int]any, error) {
+	return around(m, ctx, path, orig, mergeData, func() (map[int]any, error) {
+		return m.base.MergeIntMap(ctx, next, path, orig, mergeData)
+	})
+}
+
+func (m *aroundMerger) MergeAnyMap(ctx *Context, next Merger, path []string, orig, mergeData map[any]any) (map[any]any, error) {
+	return around(m, ctx, path, orig, mergeData, func() (map[any]any, error) {
+		return MergeAnyMap(ctx, m.base, next, path, orig, mergeData)
+	})
+}
+
+func (m *aroundMerger) MergePrimitive(ctx *Context, next Merger, path []string, orig, mergeData any) (any, error) {
+	return m.fn(ctx, path, orig, mergeData, func() (any, error) {
+		return m.base.MergePrimitive(ctx, next, path, orig, mergeData)
+	})
+}
@@ -0,0 +1,168 @@
+package merge_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestChain_RecursesThroughChain(t *testing.T) {
+	var visited []string
+	record := merge.Around(func(ctx *merge.Context, path []string, orig, data any, next func() (any, error)) (any, error) {
+		visited = append(visited, merge.Path(path).String())
+		return next()
+	})
+	skipSecrets := merge.Around(func(ctx *merge.Context, path []string, orig, data any, next func() (any, error)) (any, error) {
+		if len(path) > 0 && path[len(path)-1] == "secret" {
+			return orig, nil
+		}
+		return next()
+	})
+
+	e := merge.NewEngine()
+	mode, err := e.RegisterMode("guarded", merge.Chain(&merge.ReplaceMerger{}, record, skipSecrets))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := e.Data(mode,
+		M("db", M("host", "a", "secret", "s1"), "tags", []any{"x"}),
+		M("db", M("host", "b", "secret", "s2"), "tags", []any{"y"}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := M("db", M("host", "b", "secret", "s1"), "tags", []any{"y"})
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("Result mismatch:\nGot:      %s\nExpected: %s", toJSON(res), toJSON(want))
+	}
+	for _, p := range []string{"root", "db", "db.host", "db.secret", "tags", "tags.0"} {
+		found := false
+		for _, v := range visited {
+			found = found || v == p
+		}
+		if !found {
+			t.Errorf("node %s not visited by the outer middleware, visited: %v", p, visited)
+		}
+	}
+}
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+	named := func(name string) merge.Middleware {
+		return merge.Around(func(ctx *merge.Context, path []string, orig, data any, next func() (any, error)) (any, error) {
+			if len(path) == 0 {
+				calls = append(calls, name)
+			}
+			return next()
+		})
+	}
+
+	e := merge.NewEngine()
+	e.Use(named("engine"))
+	mode, err := e.RegisterMode("ordered", merge.Chain(&merge.InsertMerger{}, named("first"), named("second")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Data(mode, M(), M("a", 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(calls, ","); got != "engine,first,second" {
+		t.Errorf("unexpected middleware order: %s", got)
+	}
+}
+
+func TestAround_Validation(t *testing.T) {
+	positive := merge.Around(func(ctx *merge.Context, path []string, orig, data any, next func() (any, error)) (any, error) {
+		if n, ok := data.(int); ok && n < 0 {
+			return nil, fmt.Errorf("%v must not be negative", merge.Path(path))
+		}
+		return next()
+	})
+
+	e := merge.NewEngine()
+	e.Use(positive)
+	_, err := e.Data(merge.ModeFullReplace, M("limits", M("cpu", 1)), M("limits", M("cpu", -1)))
+	if err == nil || !strings.Contains(err.Error(), "limits.cpu must not be negative") {
+		t.Errorf("expected validation error, got %v", err)
+	}
+
+	// the default engine stays untouched
+	if _, err := merge.Data(merge.ModeFullReplace, M("cpu", 1), M("cpu", -1)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAround_SeesOverriddenNodes(t *testing.T) {
+	visited := map[string]int{}
+	e := merge.NewEngine()
+	e.Use(merge.Around(func(ctx *merge.Context, path []string, orig, data any, next func() (any, error)) (any, error) {
+		visited[merge.Path(path).String()]++
+		return next()
+	}))
+
+	res, err := e.Data(merge.ModeFullReplace,
+		M("tags", []any{"a"}, "path", "/bin"),
+		M("tags", []any{"b"}, "path", "/usr/bin"),
+		merge.WithArrayStrategy(merge.ArrayAppend),
+		merge.WithPrimitiveStrategy("path", merge.StringConcat(":")),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := M("tags", []any{"a", "b"}, "path", "/bin:/usr/bin"); !reflect.DeepEqual(res, want) {
+		t.Errorf("Result mismatch:\nGot:      %s\nExpected: %s", toJSON(res), toJSON(want))
+	}
+	for _, p := range []string{"root", "tags", "path"} {
+		if visited[p] != 1 {
+			t.Errorf("middleware saw %s %d times, expected once", p, visited[p])
+		}
+	}
+}
+
+// countingMerger is a middleware written as a struct embedding the
+// Merger it wraps rather than with Around.
+type countingMerger struct {
+	merge.Merger
+	arrays, primitives int
+}
+
+func (m *countingMerger) MergeArray(ctx *merge.Context, next merge.Merger, path []string, orig, data []any) ([]any, error) {
+	m.arrays++
+	return m.Merger.MergeArray(ctx, next, path, orig, data)
+}
+
+func (m *countingMerger) MergePrimitive(ctx *merge.Context, next merge.Merger, path []string, orig, data any) (any, error) {
+	m.primitives++
+	return m.Merger.MergePrimitive(ctx, next, path, orig, data)
+}
+
+func TestMiddleware_StructSeesOverriddenNodes(t *testing.T) {
+	counter := &countingMerger{}
+	e := merge.NewEngine()
+	e.Use(func(m merge.Merger) merge.Merger {
+		counter.Merger = m
+		return counter
+	})
+
+	res, err := e.Data(merge.ModeFullReplace,
+		M("tags", []any{"a"}, "path", "/bin"),
+		M("tags", []any{"b"}, "path", "/usr/bin"),
+		merge.WithArrayStrategy(merge.ArrayAppend),
+		merge.WithPrimitiveStrategy("path", merge.StringConcat(":")),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := M("tags", []any{"a", "b"}, "path", "/bin:/usr/bin"); !reflect.DeepEqual(res, want) {
+		t.Errorf("Result mismatch:\nGot:      %s\nExpected: %s", toJSON(res), toJSON(want))
+	}
+	if counter.arrays != 1 || counter.primitives != 1 {
+		t.Errorf("middleware saw %d arrays and %d primitives, expected 1 each", counter.arrays, counter.primitives)
+	}
+}
@@ -1,9 +1,17 @@
 package merge
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+)
 
 type InsertMode struct {
 	Append bool
+	// Prepend places merge data before the elements of orig.
+	Prepend bool
+	// Unique drops prepended values that are already there, it only
+	// applies together with Prepend.
+	Unique bool
 }
 
 type InsertMerger struct {
@@ -11,46 +19,18 @@ type InsertMerger struct {
 	Conf InsertMode
 }
 
-func (m *InsertMerger) MergeMap(next Merger, path []string, orig, mergeData map[string]any) (map[string]any, error) {
-	if orig == nil {
-		return mergeData, nil
-	}
-
-	for k, v := range mergeData {
-		old, exists := orig[k]
-		if !exists {
-			orig[k] = v
-			continue
-		}
-
-		// mutate path
-		path = append(path, k)
-
-		merged, err := UseMerger(next, path, old, v)
-
-		// restore
-		path = path[:len(path)-1]
-
-		if err != nil {
-			return nil, err
-		}
-
-		orig[k] = merged
-	}
-	return orig, nil
-}
-
-func (m *InsertMerger) MergeArray(next Merger, path []string, orig, mergeData []any) ([]any, error) {
+// ArrayInsert merges containers at the same index recursively and appends
+// everything else from merge data.
+func ArrayInsert(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
 	out := make([]any, len(orig))
 	copy(out, orig)
 
-	if m.Conf.Append {
-		return append(orig, mergeData...), nil
-	}
-
 	for i := range mergeData {
 		if i >= len(orig) {
-			out = append(out, mergeData[i:]...)
+			var err error
+			if out, err = ctx.appendNew(path, out, mergeData[i:]...); err != nil {
+				return nil, err
+			}
 			break
 		}
 
@@ -58,7 +38,7 @@ func (m *InsertMerger) MergeArray(next Merger, path []string, orig, mergeData []
 		case map[string]any, map[int]any, []any:
 			path = append(path, fmt.Sprintf("%v", i))
 
-			merged, err := UseMerger(next, path, orig[i], mergeData[i])
+			merged, err := UseMerger(ctx, next, path, orig[i], mergeData[i])
 
 			path = path[:len(path)-1]
 
@@ -68,27 +48,38 @@ func (m *InsertMerger) MergeArray(next Merger, path []string, orig, mergeData []
 			out[i] = merged
 
 		default:
-			out = append(out, mergeData[i])
+			var err error
+			if out, err = ctx.appendNew(path, out, mergeData[i]); err != nil {
+				return nil, err
+			}
 		}
 	}
-	return out, nil
+	return dropRemoved(out), nil
 }
 
-func (m *InsertMerger) MergeSparseArray(next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
-	out := make([]any, len(orig))
+// ArrayAppend appends merge data to orig.
+func ArrayAppend(ctx *Context, _ Merger, path []string, orig, mergeData []any) ([]any, error) {
+	out := make([]any, len(orig), len(orig)+len(mergeData))
 	copy(out, orig)
+	return ctx.appendNew(path, out, mergeData...)
+}
 
-	if m.Conf.Append {
-		return append(orig, sparseArrayToArray(mergeData)...), nil
-	}
+// SparseInsert merges values at existing indexes recursively and appends
+// the rest in index order.
+func SparseInsert(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	out := make([]any, len(orig))
+	copy(out, orig)
 
 	leftToMerge := make(map[int]any, len(mergeData))
 
 	for i, v := range mergeData {
+		if isRemoval(v) {
+			continue
+		}
 		if i < len(out) {
 			path = append(path, fmt.Sprintf("%v", i))
 
-			merged, err := UseMerger(next, path, out[i], v)
+			merged, err := UseMerger(ctx, next, path, out[i], v)
 
 			path = path[:len(path)-1]
 
@@ -102,36 +93,114 @@ func (m *InsertMerger) MergeSparseArray(next Merger, path []string, orig []any,
 		}
 	}
 
+	if err := ctx.markRemovals(path, out, len(orig), mergeData); err != nil {
+		return nil, err
+	}
+	out = dropRemoved(out)
 	if len(leftToMerge) == 0 {
 		return out, nil
 	}
 
-	return append(out, sparseArrayToArray(leftToMerge)...), nil
+	return ctx.appendNew(path, out, sparseArrayToArray(leftToMerge)...)
+}
+
+// ArrayPrepend places merge data before the elements of orig.
+func ArrayPrepend(ctx *Context, _ Merger, path []string, orig, mergeData []any) ([]any, error) {
+	return prepend(ctx, path, orig, mergeData, false)
 }
 
-func (m *InsertMerger) MergeIntMap(next Merger, path []string, orig, mergeData map[int]any) (map[int]any, error) {
-	for k, v := range mergeData {
-		old, exists := orig[k]
-		if !exists {
-			orig[k] = v
+// ArrayPrependUnique places merge data before the elements of orig,
+// dropping duplicates in merge data and the elements of orig it moves
+// to the front.
+func ArrayPrependUnique(ctx *Context, _ Merger, path []string, orig, mergeData []any) ([]any, error) {
+	return prepend(ctx, path, orig, mergeData, true)
+}
+
+// prepend adds merge data through ctx.addition, as the filters'
+// pruning of appended elements doesn't see values placed first.
+func prepend(ctx *Context, path []string, orig, mergeData []any, unique bool) ([]any, error) {
+	out := make([]any, 0, len(orig)+len(mergeData))
+	for _, v := range mergeData {
+		if unique && contains(out, v) {
 			continue
 		}
 
-		path = append(path, fmt.Sprintf("%v", k))
+		path = append(path, strconv.Itoa(len(out)))
 
-		merged, err := UseMerger(next, path, old, v)
+		added, keep, err := ctx.addition(path, v)
 
 		path = path[:len(path)-1]
 
 		if err != nil {
 			return nil, err
 		}
+		if keep {
+			out = append(out, added)
+		}
+	}
 
-		orig[k] = merged
+	prepended := len(out)
+	for _, v := range orig {
+		if unique && contains(out[:prepended], v) {
+			continue
+		}
+		out = append(out, v)
+	}
+	ctx.prunedOwn(path)
+	return out, nil
+}
+
+// SparsePrepend places the values of merge data in index order before
+// the elements of orig, ignoring the indexes themselves.
+func SparsePrepend(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	return SparseAsArray(ArrayPrepend)(ctx, next, path, orig, mergeData)
+}
+
+// SparseAppend appends the values of merge data in index order,
+// ignoring the indexes themselves.
+func SparseAppend(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	return SparseAsArray(ArrayAppend)(ctx, next, path, orig, mergeData)
+}
+
+// PrimitiveKeep keeps orig unless it's unset under the zero policy.
+func PrimitiveKeep(ctx *Context, _ Merger, _ []string, orig, mergeData any) (any, error) {
+	if ctx.IsZero(orig) {
+		return mergeData, nil
 	}
 	return orig, nil
 }
 
-func (m *InsertMerger) MergePrimitive(_ Merger, _ []string, orig, mergeData any) (any, error) {
-	return nonZero(orig, mergeData), nil
+func (m *InsertMerger) MergeMap(ctx *Context, next Merger, path []string, orig, mergeData map[string]any) (map[string]any, error) {
+	return MapInsert.MergeMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *InsertMerger) MergeArray(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	switch {
+	case m.Conf.Prepend && m.Conf.Unique:
+		return ArrayPrependUnique(ctx, next, path, orig, mergeData)
+	case m.Conf.Prepend:
+		return ArrayPrepend(ctx, next, path, orig, mergeData)
+	case m.Conf.Append:
+		return ArrayAppend(ctx, next, path, orig, mergeData)
+	}
+	return ArrayInsert(ctx, next, path, orig, mergeData)
+}
+
+func (m *InsertMerger) MergeSparseArray(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	if m.Conf.Prepend || m.Conf.Append {
+		return SparseAsArray(m.MergeArray)(ctx, next, path, orig, mergeData)
+	}
+	return SparseInsert(ctx, next, path, orig, mergeData)
+}
+
+func (m *InsertMerger) MergeIntMap(ctx *Context, next Merger, path []string, orig, mergeData map[int]any) (map[int]any, error) {
+	return MapInsert.MergeIntMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *InsertMerger) MergeAnyMap(ctx *Context, next Merger, path []string, orig, mergeData map[any]any) (map[any]any, error) {
+	return MapInsert.MergeAnyMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *InsertMerger) MergePrimitive(ctx *Context, next Merger, path []string, orig, mergeData any) (any, error) {
+	return PrimitiveKeep(ctx, next, path, orig, mergeData)
 }
@@ -0,0 +1,386 @@
+package merge
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Stamped is a leaf value written at a logical time by a replica, for
+// ModeLWW. Deleted marks a tombstone: the value was removed at Time and
+// older writes mustn't bring it back.
+type Stamped struct {
+	Value   any
+	Time    uint64
+	Replica string
+	Deleted bool
+	// Kept holds the parts of a map or array this stamp replaced or
+	// deleted that were written after it, so they win over the stamp and
+	// older writes to the rest don't come back. ModeLWW sets it when such
+	// writes meet; Unstamp returns it in place of Value.
+	Kept any
+}
+
+// LWWMerger is a last-writer-wins CRDT: leaves are Stamped values and the
+// newest one wins, maps are merged key by key and arrays element by
+// element. Merges are commutative, associative and idempotent, so replicas
+// converge whatever order they merge each other's state in.
+//
+// A Stamped value meeting a map or array, e.g. a replica deleting a map
+// another one edits, keeps the writes below it that are newer than the
+// stamp, see Stamped.Kept.
+type LWWMerger struct {
+	Mode Mode
+}
+
+// Stamp wraps every leaf of a merge tree, including array elements, in a
+// Stamped written at time by replica.
+func Stamp(v any, time uint64, replica string) any {
+	switch t := v.(type) {
+	case map[string]any:
+		return stampMap(t, time, replica)
+	case map[int]any:
+		return stampMap(t, time, replica)
+	case []any:
+		out := make([]any, len(t))
+		for i, elem := range t {
+			out[i] = Stamp(elem, time, replica)
+		}
+		return out
+	case Stamped:
+		return t
+	}
+	return Stamped{Value: v, Time: time, Replica: replica}
+}
+
+// Tombstone returns the leaf marking a value deleted at time by replica.
+func Tombstone(time uint64, replica string) Stamped {
+	return Stamped{Time: time, Replica: replica, Deleted: true}
+}
+
+// Unstamp returns the plain values of a stamped merge tree,
+// dropping tombstoned keys and elements.
+func Unstamp(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		return unstampMap(t)
+	case map[int]any:
+		return unstampMap(t)
+	case []any:
+		out := make([]any, 0, len(t))
+		for _, elem := range t {
+			if !isTombstone(elem) {
+				out = append(out, Unstamp(elem))
+			}
+		}
+		return out
+	case Stamped:
+		switch {
+		case t.Kept != nil:
+			return Unstamp(t.Kept)
+		case t.Deleted:
+			return nil
+		}
+		return t.Value
+	}
+	return v
+}
+
+// isTombstone reports whether v is deleted with nothing written after.
+func isTombstone(v any) bool {
+	s, ok := v.(Stamped)
+	return ok && s.Deleted && s.Kept == nil
+}
+
+func stampMap[K comparable](m map[K]any, time uint64, replica string) map[K]any {
+	out := make(map[K]any, len(m))
+	for k, v := range m {
+		out[k] = Stamp(v, time, replica)
+	}
+	return out
+}
+
+func unstampMap[K comparable](m map[K]any) map[K]any {
+	out := make(map[K]any, len(m))
+	for k, v := range m {
+		if !isTombstone(v) {
+			out[k] = Unstamp(v)
+		}
+	}
+	return out
+}
+
+// PrimitiveLWW takes the newest of two values. Stamped values win over
+// plain ones; ties are broken by replica, then by tombstones winning, then
+// by comparing the values, so the result never depends on the order.
+func PrimitiveLWW(_ *Context, _ Merger, _ []string, orig, mergeData any) (any, error) {
+	if hasKept(orig) || hasKept(mergeData) || isContainer(orig) || isContainer(mergeData) {
+		return joinLWW(orig, mergeData), nil
+	}
+	if newer(mergeData, orig) {
+		return mergeData, nil
+	}
+	return orig, nil
+}
+
+// newer reports whether a wins over b.
+func newer(a, b any) bool {
+	sa, stampedA := a.(Stamped)
+	sb, stampedB := b.(Stamped)
+	switch {
+	case stampedA != stampedB:
+		return stampedA
+	case !stampedA:
+		return compareValues(a, b) > 0
+	case sa.Time != sb.Time:
+		return sa.Time > sb.Time
+	case sa.Replica != sb.Replica:
+		return sa.Replica > sb.Replica
+	case sa.Deleted != sb.Deleted:
+		return sa.Deleted
+	}
+	return compareValues(sa.Value, sb.Value) > 0
+}
+
+func hasKept(v any) bool {
+	s, ok := v.(Stamped)
+	return ok && s.Kept != nil
+}
+
+// joinLWW merges two nodes involving a Stamped value and a container, or
+// a Stamped value with Kept writes. The newest stamp of both wins, and the
+// merged containers are pruned down to the writes newer than it.
+func joinLWW(a, b any) any {
+	if !isContainer(a) && !isContainer(b) && !hasKept(a) && !hasKept(b) {
+		if newer(b, a) {
+			return b
+		}
+		return a
+	}
+
+	floorA, contentA := lwwParts(a)
+	floorB, contentB := lwwParts(b)
+	floor := floorA
+	if floorB != nil && (floor == nil || newer(*floorB, *floor)) {
+		floor = floorB
+	}
+	content := joinContent(contentA, contentB)
+	if floor == nil {
+		return content
+	}
+	kept, live := pruneLWW(content, *floor)
+	if !live {
+		return *floor
+	}
+	res := *floor
+	res.Kept = kept
+	return res
+}
+
+// lwwParts splits a node into the stamp that last replaced it and the
+// container written after that. Plain values lose to both.
+func lwwParts(v any) (*Stamped, any) {
+	switch t := v.(type) {
+	case Stamped:
+		content := t.Kept
+		t.Kept = nil
+		return &t, content
+	case map[string]any, map[int]any, []any:
+		return nil, v
+	}
+	return nil, nil
+}
+
+// joinContent merges two containers key by key and element by element.
+// Containers of different kinds can't be merged, the one with the greater
+// type name is taken so the result doesn't depend on the order.
+func joinContent(a, b any) any {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	switch ta := a.(type) {
+	case map[string]any:
+		if tb, ok := b.(map[string]any); ok {
+			return joinMaps(ta, tb)
+		}
+	case map[int]any:
+		if tb, ok := b.(map[int]any); ok {
+			return joinMaps(ta, tb)
+		}
+	case []any:
+		if tb, ok := b.([]any); ok {
+			out := make([]any, max(len(ta), len(tb)))
+			for i := range out {
+				switch {
+				case i >= len(ta):
+					out[i] = tb[i]
+				case i >= len(tb):
+					out[i] = ta[i]
+				default:
+					out[i] = joinLWW(ta[i], tb[i])
+				}
+			}
+			return out
+		}
+	}
+	if fmt.Sprintf("%T", b) > fmt.Sprintf("%T", a) {
+		return b
+	}
+	return a
+}
+
+func joinMaps[K comparable](a, b map[K]any) map[K]any {
+	out := maps.Clone(a)
+	for k, v := range b {
+		if old, ok := out[k]; ok {
+			v = joinLWW(old, v)
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// pruneLWW drops the writes in v that aren't after floor, live reports
+// whether any are. Array elements are replaced by tombstones at the
+// floor's time to keep the indexes of the others, trailing ones are cut
+// so the length doesn't depend on what was pruned before.
+func pruneLWW(v any, floor Stamped) (pruned any, live bool) {
+	switch t := v.(type) {
+	case map[string]any:
+		return pruneLWWMap(t, floor)
+	case map[int]any:
+		return pruneLWWMap(t, floor)
+	case []any:
+		out := make([]any, len(t))
+		for i, elem := range t {
+			p, ok := pruneLWW(elem, floor)
+			if !ok {
+				p = Tombstone(floor.Time, floor.Replica)
+			}
+			out[i], live = p, live || ok
+		}
+		for len(out) > 0 && out[len(out)-1] == Tombstone(floor.Time, floor.Replica) {
+			out = out[:len(out)-1]
+		}
+		return out, live
+	case Stamped:
+		if writtenAfter(t, floor) {
+			return t, true
+		}
+		if t.Kept == nil {
+			return nil, false
+		}
+		return pruneLWW(t.Kept, floor)
+	}
+	return nil, false
+}
+
+func pruneLWWMap[K comparable](m map[K]any, floor Stamped) (map[K]any, bool) {
+	out := make(map[K]any, len(m))
+	for k, v := range m {
+		if p, ok := pruneLWW(v, floor); ok {
+			out[k] = p
+		}
+	}
+	return out, len(out) > 0
+}
+
+// writtenAfter reports whether s was written after floor. Writes with the
+// same time and replica are the one that set floor, so they aren't.
+func writtenAfter(s, floor Stamped) bool {
+	if s.Time != floor.Time {
+		return s.Time > floor.Time
+	}
+	return s.Replica > floor.Replica
+}
+
+// joinOver merges the containers of orig that merge data overwrites with
+// a Stamped value in place, as UseMerger can't merge a container with a
+// value, and returns merge data without them.
+func joinOver[K comparable](orig, mergeData map[K]any) map[K]any {
+	var rest map[K]any
+	for k, v := range mergeData {
+		if _, stamped := v.(Stamped); !stamped || !isContainer(orig[k]) {
+			continue
+		}
+		if rest == nil {
+			rest = maps.Clone(mergeData)
+		}
+		orig[k] = joinLWW(orig[k], v)
+		delete(rest, k)
+	}
+	if rest == nil {
+		return mergeData
+	}
+	return rest
+}
+
+// joinsOverElems reports whether merge data overwrites a container of
+// orig with a Stamped value.
+func joinsOverElems(orig, mergeData []any) bool {
+	for i, v := range mergeData[:min(len(orig), len(mergeData))] {
+		if _, stamped := v.(Stamped); stamped && isContainer(orig[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *LWWMerger) MergeMap(ctx *Context, next Merger, path []string, orig, mergeData map[string]any) (map[string]any, error) {
+	if orig != nil {
+		mergeData = joinOver(orig, mergeData)
+	}
+	return MapInsert.MergeMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *LWWMerger) MergeIntMap(ctx *Context, next Merger, path []string, orig, mergeData map[int]any) (map[int]any, error) {
+	if orig != nil {
+		mergeData = joinOver(orig, mergeData)
+	}
+	return MapInsert.MergeIntMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *LWWMerger) MergeAnyMap(ctx *Context, next Merger, path []string, orig, mergeData map[any]any) (map[any]any, error) {
+	if orig != nil {
+		mergeData = joinOver(orig, mergeData)
+	}
+	return MapInsert.MergeAnyMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *LWWMerger) MergeArray(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	if !joinsOverElems(orig, mergeData) {
+		return ArrayElementwise(ctx, next, path, orig, mergeData)
+	}
+	// merged as sparse data, so the elements joined with orig are left
+	// out of merge data rather than set to a value
+	sparse := make(map[int]any, len(mergeData))
+	for i, v := range mergeData {
+		sparse[i] = v
+	}
+	return m.MergeSparseArray(ctx, next, path, orig, sparse)
+}
+
+func (m *LWWMerger) MergeSparseArray(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	var rest map[int]any
+	for i, v := range mergeData {
+		if _, stamped := v.(Stamped); !stamped || i < 0 || i >= len(orig) || !isContainer(orig[i]) {
+			continue
+		}
+		if rest == nil {
+			orig, rest = slices.Clone(orig), maps.Clone(mergeData)
+		}
+		orig[i] = joinLWW(orig[i], v)
+		delete(rest, i)
+	}
+	if rest != nil {
+		mergeData = rest
+	}
+	return SparseByIndex(ctx, next, path, orig, mergeData)
+}
+
+func (m *LWWMerger) MergePrimitive(ctx *Context, next Merger, path []string, orig, mergeData any) (any, error) {
+	return PrimitiveLWW(ctx, next, path, orig, mergeData)
+}
@@ -0,0 +1,204 @@
+package merge
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ErrNotNumeric is returned by the numeric modes for values that aren't
+// numbers, unless WithNonNumeric says otherwise.
+var ErrNotNumeric = errors.New("value is not a number")
+
+// NonNumeric decides what the numeric modes do when orig or merge data
+// isn't a number.
+type NonNumeric int
+
+const (
+	// NonNumericError fails with ErrNotNumeric. It's the default.
+	NonNumericError NonNumeric = iota
+	// NonNumericKeep keeps orig.
+	NonNumericKeep
+	// NonNumericReplace takes the merge data value.
+	NonNumericReplace
+)
+
+// WithNonNumeric sets what the numeric modes do with values that aren't numbers.
+func WithNonNumeric(p NonNumeric) Option {
+	return func(o *Options) { o.NonNumeric = p }
+}
+
+// WithAvgCounts seeds the number of values averaged so far at each path
+// for ModeAvg, values missing there count as one. Paths are joined with
+// dots, with dots and backslashes in keys escaped by a backslash. The map
+// is updated after every layer that isn't rejected, so passing the same
+// map to later calls continues the averages.
+func WithAvgCounts(counts map[string]int) Option {
+	return func(o *Options) { o.AvgCounts = counts }
+}
+
+// NumericMerger combines numbers with Combine instead of replacing them.
+// Maps are merged like ModeInsert, arrays element by element.
+type NumericMerger struct {
+	Mode    Mode
+	Combine PrimitiveStrategy
+}
+
+// ArrayElementwise merges elements at the same index recursively and
+// appends the ones orig doesn't have, never dropping any.
+func ArrayElementwise(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	return mergeByIndex(ctx, next, path, orig, mergeData, byIndexGrow)
+}
+
+// PrimitiveSum adds numbers. Numbers of the same type keep it,
+// others are added as float64.
+func PrimitiveSum(ctx *Context, _ Merger, _ []string, orig, mergeData any) (any, error) {
+	return ctx.combine(orig, mergeData, func(a, b any) any {
+		return arith(a, b,
+			func(x, y int64) int64 { return x + y },
+			func(x, y uint64) uint64 { return x + y },
+			func(x, y float64) float64 { return x + y },
+		)
+	})
+}
+
+// PrimitiveMax takes the greater number.
+func PrimitiveMax(ctx *Context, _ Merger, _ []string, orig, mergeData any) (any, error) {
+	return ctx.combine(orig, mergeData, func(a, b any) any {
+		if compareValues(b, a) > 0 {
+			return b
+		}
+		return a
+	})
+}
+
+// PrimitiveMin takes the lesser number.
+func PrimitiveMin(ctx *Context, _ Merger, _ []string, orig, mergeData any) (any, error) {
+	return ctx.combine(orig, mergeData, func(a, b any) any {
+		if compareValues(b, a) < 0 {
+			return b
+		}
+		return a
+	})
+}
+
+// PrimitiveAvg keeps a running average, weighting orig by the number of
+// values averaged into it so far, see WithAvgCounts. The average keeps
+// the type of orig, so it fits typed maps; integers are rounded.
+func PrimitiveAvg(ctx *Context, _ Merger, path []string, orig, mergeData any) (any, error) {
+	key := pathKey(path)
+	if orig == nil {
+		ctx.avgPending[key] = 1
+		return mergeData, nil
+	}
+	return ctx.combine(orig, mergeData, func(a, b any) any {
+		n, ok := ctx.avgCounts[key]
+		if !ok {
+			n = 1
+		}
+		ctx.avgPending[key] = n + 1
+		fa, _ := toFloat(a)
+		fb, _ := toFloat(b)
+		return numberLike(fa+(fb-fa)/float64(n+1), a)
+	})
+}
+
+// numberLike converts f to the type of the number like.
+func numberLike(f float64, like any) any {
+	v := reflect.New(reflect.TypeOf(like)).Elem()
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(math.Round(f)))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(uint64(math.Round(f)))
+	default:
+		v.SetFloat(f)
+	}
+	return v.Interface()
+}
+
+// commitAvgCounts keeps the counts of a layer once it's accepted,
+// so rejected layers don't weigh on later averages.
+func (c *Context) commitAvgCounts() {
+	for key, n := range c.avgPending {
+		c.avgCounts[key] = n
+	}
+	clear(c.avgPending)
+}
+
+// combine applies op to two numbers. A nil orig takes merge data,
+// nil merge data keeps orig.
+func (c *Context) combine(orig, mergeData any, op func(a, b any) any) (any, error) {
+	if mergeData == nil {
+		return orig, nil
+	}
+	if orig == nil {
+		return mergeData, nil
+	}
+	_, numOrig := toFloat(orig)
+	_, numData := toFloat(mergeData)
+	if numOrig && numData {
+		return op(orig, mergeData), nil
+	}
+
+	switch c.opts.NonNumeric {
+	case NonNumericKeep:
+		return orig, nil
+	case NonNumericReplace:
+		return mergeData, nil
+	}
+	return nil, fmt.Errorf("%w: %T and %T", ErrNotNumeric, orig, mergeData)
+}
+
+// arith applies an operation to two numbers, keeping their type
+// when they share one and using float64 otherwise.
+func arith(
+	a, b any,
+	ints func(x, y int64) int64,
+	uints func(x, y uint64) uint64,
+	floats func(x, y float64) float64,
+) any {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() == vb.Type() {
+		out := reflect.New(va.Type()).Elem()
+		switch va.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			out.SetInt(ints(va.Int(), vb.Int()))
+			return out.Interface()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			out.SetUint(uints(va.Uint(), vb.Uint()))
+			return out.Interface()
+		case reflect.Float32, reflect.Float64:
+			out.SetFloat(floats(va.Float(), vb.Float()))
+			return out.Interface()
+		}
+	}
+	fa, _ := toFloat(a)
+	fb, _ := toFloat(b)
+	return floats(fa, fb)
+}
+
+func (m *NumericMerger) MergeMap(ctx *Context, next Merger, path []string, orig, mergeData map[string]any) (map[string]any, error) {
+	return MapInsert.MergeMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *NumericMerger) MergeIntMap(ctx *Context, next Merger, path []string, orig, mergeData map[int]any) (map[int]any, error) {
+	return MapInsert.MergeIntMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *NumericMerger) MergeAnyMap(ctx *Context, next Merger, path []string, orig, mergeData map[any]any) (map[any]any, error) {
+	return MapInsert.MergeAnyMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *NumericMerger) MergeArray(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	return ArrayElementwise(ctx, next, path, orig, mergeData)
+}
+
+func (m *NumericMerger) MergeSparseArray(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	return SparseByIndex(ctx, next, path, orig, mergeData)
+}
+
+func (m *NumericMerger) MergePrimitive(ctx *Context, next Merger, path []string, orig, mergeData any) (any, error) {
+	return m.Combine(ctx, next, path, orig, mergeData)
+}
@@ -11,44 +11,45 @@ type ReplaceMerger struct {
 	Conf ReplaceMode
 }
 
-func replaceMergeMap[K comparable](
-	next Merger,
-	path []string,
-	orig, mergeData map[K]any,
-	conf ReplaceMode,
-) (map[K]any, error) {
-
-	for k, v := range mergeData {
-		old, exists := orig[k]
-		if conf.Partial && !exists {
-			continue
-		}
-
-		if exists {
-			path = append(path, fmt.Sprintf("%v", k))
-
-			merged, err := UseMerger(next, path, old, v)
-
-			path = path[:len(path)-1]
+// byIndex tells how arrays are merged index by index.
+type byIndex int
+
+const (
+	// byIndexFull grows or truncates orig to the length of merge data.
+	byIndexFull byIndex = iota
+	// byIndexPartial never grows or truncates orig.
+	byIndexPartial
+	// byIndexSet is byIndexFull replacing elements of other kinds.
+	byIndexSet
+	// byIndexGrow grows orig to the length of merge data, never truncates.
+	byIndexGrow
+)
+
+func (b byIndex) merge(ctx *Context, next Merger, path []string, orig, mergeData any) (any, error) {
+	if b == byIndexSet {
+		return setValue(ctx, next, path, orig, mergeData)
+	}
+	return UseMerger(ctx, next, path, orig, mergeData)
+}
 
-			if err != nil {
-				return nil, err
-			}
+// ArrayByIndex merges elements at the same index recursively, appends the
+// ones orig doesn't have and drops the ones merge data doesn't have.
+func ArrayByIndex(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	return mergeByIndex(ctx, next, path, orig, mergeData, byIndexFull)
+}
 
-			orig[k] = merged
-		} else {
-			orig[k] = v
-		}
-	}
-	return orig, nil
+// ArrayByIndexPartial merges elements at the same index recursively
+// and never grows orig.
+func ArrayByIndexPartial(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	return mergeByIndex(ctx, next, path, orig, mergeData, byIndexPartial)
 }
 
-func (m *ReplaceMerger) MergeArray(next Merger, path []string, orig, mergeData []any) ([]any, error) {
+func mergeByIndex(ctx *Context, next Merger, path []string, orig, mergeData []any, mode byIndex) ([]any, error) {
 	out := make([]any, len(orig))
 	copy(out, orig)
 
 	limit := len(mergeData)
-	if m.Conf.Partial && limit > len(out) {
+	if mode == byIndexPartial && limit > len(out) {
 		limit = len(out)
 	}
 
@@ -56,7 +57,7 @@ func (m *ReplaceMerger) MergeArray(next Merger, path []string, orig, mergeData [
 		if i < len(out) {
 			path = append(path, fmt.Sprintf("%v", i))
 
-			merged, err := UseMerger(next, path, out[i], mergeData[i])
+			merged, err := mode.merge(ctx, next, path, out[i], mergeData[i])
 
 			path = path[:len(path)-1]
 
@@ -66,21 +67,46 @@ func (m *ReplaceMerger) MergeArray(next Merger, path []string, orig, mergeData [
 
 			out[i] = merged
 		} else {
-			out = append(out, mergeData[i])
+			var err error
+			if out, err = ctx.appendNew(path, out, mergeData[i]); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	if !m.Conf.Partial && len(mergeData) > len(out) {
-		out = append(out, mergeData[len(out):]...)
+	if mode == byIndexFull || mode == byIndexSet {
+		var err error
+		if out, err = ctx.truncate(path, out, len(mergeData)); err != nil {
+			return nil, err
+		}
 	}
 
+	return dropRemoved(out), nil
+}
+
+// ArrayReplace takes the merge data array as a whole.
+func ArrayReplace(_ *Context, _ Merger, _ []string, _, mergeData []any) ([]any, error) {
+	out := make([]any, len(mergeData))
+	copy(out, mergeData)
 	return out, nil
 }
 
-func (m *ReplaceMerger) MergeSparseArray(next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
-	if mergeData == nil {
-		return nil, nil
-	}
+// SparseByIndex merges values at existing indexes recursively
+// and appends the rest in index order.
+func SparseByIndex(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	return mergeSparseByIndex(ctx, next, path, orig, mergeData, byIndexFull)
+}
+
+// SparseByIndexPartial merges values at existing indexes recursively
+// and ignores the rest.
+func SparseByIndexPartial(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	return mergeSparseByIndex(ctx, next, path, orig, mergeData, byIndexPartial)
+}
+
+// mergeSparseByIndex never truncates, indexes missing in merge data are
+// left as they are.
+func mergeSparseByIndex(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any, mode byIndex) ([]any, error) {
+	partial := mode == byIndexPartial
 
 	out := make([]any, len(orig))
 	copy(out, orig)
@@ -94,18 +120,18 @@ func (m *ReplaceMerger) MergeSparseArray(next Merger, path []string, orig []any,
 
 	for i := 0; i <= maxIdx; i++ {
 		v, ok := mergeData[i]
-		if !ok {
+		if !ok || isRemoval(v) {
 			continue
 		}
 
 		if i < len(out) {
-			if m.Conf.Partial && i >= len(orig) {
+			if partial && i >= len(orig) {
 				break
 			}
 
 			path = append(path, fmt.Sprintf("%v", i))
 
-			merged, err := UseMerger(next, path, out[i], v)
+			merged, err := mode.merge(ctx, next, path, out[i], v)
 
 			path = path[:len(path)-1]
 
@@ -115,30 +141,74 @@ func (m *ReplaceMerger) MergeSparseArray(next Merger, path []string, orig []any,
 
 			out[i] = merged
 
-		} else if !m.Conf.Partial {
-			out = append(out, v)
+		} else if !partial {
+			var err error
+			if out, err = ctx.appendNew(path, out, v); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	return out, nil
+	if err := ctx.markRemovals(path, out, len(orig), mergeData); err != nil {
+		return nil, err
+	}
+	return dropRemoved(out), nil
+}
+
+// PrimitiveReplace always takes the merge data value.
+func PrimitiveReplace(_ *Context, _ Merger, _ []string, _, mergeData any) (any, error) {
+	return mergeData, nil
+}
+
+func (m *ReplaceMerger) mapStrategy() MapStrategy {
+	if m.Conf.Partial {
+		return MapUpdate
+	}
+	return MapInsert
+}
+
+func (m *ReplaceMerger) byIndex() byIndex {
+	if m.Conf.Partial {
+		return byIndexPartial
+	}
+	return byIndexFull
+}
+
+func (m *ReplaceMerger) MergeArray(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	return mergeByIndex(ctx, next, path, orig, mergeData, m.byIndex())
+}
+
+func (m *ReplaceMerger) MergeSparseArray(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	return mergeSparseByIndex(ctx, next, path, orig, mergeData, m.byIndex())
 }
 
 func (m *ReplaceMerger) MergeMap(
+	ctx *Context,
 	next Merger,
 	path []string,
 	orig, mergeData map[string]any,
 ) (map[string]any, error) {
-	return replaceMergeMap(next, path, orig, mergeData, m.Conf)
+	return m.mapStrategy().MergeMap(ctx, next, path, orig, mergeData)
 }
 
 func (m *ReplaceMerger) MergeIntMap(
+	ctx *Context,
 	next Merger,
 	path []string,
 	orig, mergeData map[int]any,
 ) (map[int]any, error) {
-	return replaceMergeMap(next, path, orig, mergeData, m.Conf)
+	return m.mapStrategy().MergeIntMap(ctx, next, path, orig, mergeData)
 }
 
-func (m *ReplaceMerger) MergePrimitive(_ Merger, _ []string, _, mergeData any) (any, error) {
-	return mergeData, nil
+func (m *ReplaceMerger) MergePrimitive(ctx *Context, next Merger, path []string, orig, mergeData any) (any, error) {
+	return PrimitiveReplace(ctx, next, path, orig, mergeData)
+}
+
+func (m *ReplaceMerger) MergeAnyMap(
+	ctx *Context,
+	next Merger,
+	path []string,
+	orig, mergeData map[any]any,
+) (map[any]any, error) {
+	return m.mapStrategy().MergeAnyMap(ctx, next, path, orig, mergeData)
 }
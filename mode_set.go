@@ -0,0 +1,111 @@
+package merge
+
+import "reflect"
+
+// SetMerger replaces whole values: maps end up with exactly the keys of
+// merge data, arrays with its elements and containers of another kind are
+// replaced outright. Values are still set node by node, so filters,
+// customizers and policies see every path.
+type SetMerger struct {
+	Mode Mode
+}
+
+// ArraySet takes the merge data array, setting elements at the same index.
+func ArraySet(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	return mergeByIndex(ctx, next, path, orig, mergeData, byIndexSet)
+}
+
+// SparseSet sets the values at the indexes of merge data
+// and appends the rest in index order.
+func SparseSet(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	return mergeSparseByIndex(ctx, next, path, orig, mergeData, byIndexSet)
+}
+
+// setValue merges mergeData into orig with next, dropping orig first when
+// it's a container of another kind, which mergers would reject.
+func setValue(ctx *Context, next Merger, path []string, orig, mergeData any) (any, error) {
+	if mergeData != nil && isBranch(orig) && !sameKind(orig, mergeData) &&
+		ctx.filter(path) == filterAllow {
+		orig = nil
+	}
+	return UseMerger(ctx, next, path, orig, mergeData)
+}
+
+func sameKind(orig, mergeData any) bool {
+	if _, ok := orig.([]any); ok {
+		switch mergeData.(type) {
+		case []any, map[int]any:
+			return true
+		}
+		return false
+	}
+	return reflect.TypeOf(orig) == reflect.TypeOf(mergeData)
+}
+
+// setMap returns a map with the keys of mergeData, keys of orig only
+// survive when filters keep them from being removed.
+func setMap[K comparable](ctx *Context, next Merger, path []string, orig, mergeData map[K]any) (map[K]any, error) {
+	out := make(map[K]any, len(mergeData))
+	for k, old := range orig {
+		if _, exists := mergeData[k]; exists {
+			continue
+		}
+		p := append(path, pathSegment(k))
+		if !ctx.removable(p, old) {
+			if _, err := ctx.skip(p, old); err != nil {
+				return nil, err
+			}
+			out[k] = old
+		}
+	}
+
+	for k, v := range mergeData {
+		var (
+			res  any
+			keep = true
+			err  error
+		)
+
+		path = append(path, pathSegment(k))
+
+		if old, exists := orig[k]; exists {
+			res, err = setValue(ctx, next, path, old, v)
+		} else {
+			res, keep, err = ctx.addition(path, v)
+		}
+
+		path = path[:len(path)-1]
+
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			storeKey(out, k, res)
+		}
+	}
+	return out, nil
+}
+
+func (m *SetMerger) MergeMap(ctx *Context, next Merger, path []string, orig, mergeData map[string]any) (map[string]any, error) {
+	return setMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *SetMerger) MergeIntMap(ctx *Context, next Merger, path []string, orig, mergeData map[int]any) (map[int]any, error) {
+	return setMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *SetMerger) MergeAnyMap(ctx *Context, next Merger, path []string, orig, mergeData map[any]any) (map[any]any, error) {
+	return setMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *SetMerger) MergeArray(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	return ArraySet(ctx, next, path, orig, mergeData)
+}
+
+func (m *SetMerger) MergeSparseArray(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	return SparseSet(ctx, next, path, orig, mergeData)
+}
+
+func (m *SetMerger) MergePrimitive(ctx *Context, next Merger, path []string, orig, mergeData any) (any, error) {
+	return PrimitiveReplace(ctx, next, path, orig, mergeData)
+}
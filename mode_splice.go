@@ -0,0 +1,68 @@
+package merge
+
+import (
+	"sort"
+	"strconv"
+)
+
+// SparseSplice inserts the values of merge data before the element at
+// their index in orig, shifting the following elements. Negative indexes
+// count from the end (-1 is before the last element), indexes past the
+// end append. All indexes refer to orig, values for the same position
+// are inserted in index order. Removal markers remove elements of orig,
+// so a removal and an insert at the same index replace elements.
+func SparseSplice(ctx *Context, _ Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	kept := make([]any, len(orig))
+	copy(kept, orig)
+	if err := ctx.markRemovals(path, kept, len(orig), mergeData); err != nil {
+		return nil, err
+	}
+
+	type insert struct {
+		pos, idx int
+		v        any
+	}
+	inserts := make([]insert, 0, len(mergeData))
+	for i, v := range mergeData {
+		if isRemoval(v) {
+			continue
+		}
+		pos := i
+		if pos < 0 {
+			pos = max(len(orig)+pos, 0)
+		}
+		inserts = append(inserts, insert{pos: min(pos, len(orig)), idx: i, v: v})
+	}
+	sort.Slice(inserts, func(a, b int) bool {
+		if inserts[a].pos != inserts[b].pos {
+			return inserts[a].pos < inserts[b].pos
+		}
+		return inserts[a].idx < inserts[b].idx
+	})
+
+	// inserted values shift orig, so they're pruned and customized here
+	// at their final index instead of as appended elements
+	out := make([]any, 0, len(orig)+len(inserts))
+	next := 0
+	for pos := 0; pos <= len(orig); pos++ {
+		for ; next < len(inserts) && inserts[next].pos == pos; next++ {
+			path = append(path, strconv.Itoa(len(out)))
+
+			added, keep, err := ctx.addition(path, inserts[next].v)
+
+			path = path[:len(path)-1]
+
+			if err != nil {
+				return nil, err
+			}
+			if keep {
+				out = append(out, added)
+			}
+		}
+		if pos < len(orig) && kept[pos] != removed {
+			out = append(out, orig[pos])
+		}
+	}
+	ctx.prunedOwn(path)
+	return out, nil
+}
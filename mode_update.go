@@ -4,53 +4,31 @@ import "fmt"
 
 type UpdateMerger struct{ Mode Mode }
 
-func updateMergeMap[K comparable](
-	next Merger,
-	path []string,
-	orig, mergeData map[K]any,
-) (map[K]any, error) {
-
-	for k, v := range mergeData {
-		old, exists := orig[k]
-		if !exists {
-			continue
-		}
-
-		// mutate path in-place
-		seg := fmt.Sprintf("%v", k)
-		path = append(path, seg)
-
-		merged, err := UseMerger(next, path, old, v)
-
-		// restore path
-		path = path[:len(path)-1]
-
-		if err != nil {
-			return nil, err
-		}
-
-		orig[k] = merged
-	}
-	return orig, nil
-}
-
-func (m *UpdateMerger) MergeArray(_ Merger, _ []string, orig, mergeData []any) ([]any, error) {
+// ArrayUnique appends the values of merge data orig doesn't contain yet
+// (deep equality), keeping the order of both.
+func ArrayUnique(ctx *Context, _ Merger, path []string, orig, mergeData []any) ([]any, error) {
 	out := make([]any, len(orig))
 	copy(out, orig)
 	for _, v := range mergeData {
-		if !contains(out, v) {
-			out = append(out, v)
+		if contains(out, v) {
+			continue
+		}
+		var err error
+		if out, err = ctx.appendNew(path, out, v); err != nil {
+			return nil, err
 		}
 	}
 	return out, nil
 }
 
-func (m *UpdateMerger) MergeSparseArray(next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+// SparseUpdate merges values at existing indexes recursively
+// and ignores the rest.
+func SparseUpdate(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
 	out := make([]any, len(orig))
 	copy(out, orig)
 
 	for i, v := range mergeData {
-		if i >= len(out) {
+		if i >= len(out) || isRemoval(v) {
 			continue
 		}
 
@@ -59,7 +37,7 @@ func (m *UpdateMerger) MergeSparseArray(next Merger, path []string, orig []any,
 		// path mutation
 		path = append(path, fmt.Sprintf("%v", i))
 
-		merged, err := UseMerger(next, path, old, v)
+		merged, err := UseMerger(ctx, next, path, old, v)
 
 		// restore
 		path = path[:len(path)-1]
@@ -71,20 +49,41 @@ func (m *UpdateMerger) MergeSparseArray(next Merger, path []string, orig []any,
 		out[i] = merged
 	}
 
-	return out, nil
+	if err := ctx.markRemovals(path, out, len(orig), mergeData); err != nil {
+		return nil, err
+	}
+	return dropRemoved(out), nil
 }
 
-func (m *UpdateMerger) MergePrimitive(_ Merger, _ []string, orig, mergeData any) (any, error) {
-	if isZeroValue(orig) {
+// PrimitiveUpdate takes the merge data value unless orig is unset
+// under the zero policy.
+func PrimitiveUpdate(ctx *Context, _ Merger, _ []string, orig, mergeData any) (any, error) {
+	if ctx.IsZero(orig) {
 		return orig, nil
 	}
 	return mergeData, nil
 }
 
-func (m *UpdateMerger) MergeMap(next Merger, path []string, orig, mergeData map[string]any) (map[string]any, error) {
-	return updateMergeMap(next, path, orig, mergeData)
+func (m *UpdateMerger) MergeArray(ctx *Context, next Merger, path []string, orig, mergeData []any) ([]any, error) {
+	return ArrayUnique(ctx, next, path, orig, mergeData)
+}
+
+func (m *UpdateMerger) MergeSparseArray(ctx *Context, next Merger, path []string, orig []any, mergeData map[int]any) ([]any, error) {
+	return SparseUpdate(ctx, next, path, orig, mergeData)
+}
+
+func (m *UpdateMerger) MergePrimitive(ctx *Context, next Merger, path []string, orig, mergeData any) (any, error) {
+	return PrimitiveUpdate(ctx, next, path, orig, mergeData)
+}
+
+func (m *UpdateMerger) MergeMap(ctx *Context, next Merger, path []string, orig, mergeData map[string]any) (map[string]any, error) {
+	return MapUpdate.MergeMap(ctx, next, path, orig, mergeData)
+}
+
+func (m *UpdateMerger) MergeIntMap(ctx *Context, next Merger, path []string, orig, mergeData map[int]any) (map[int]any, error) {
+	return MapUpdate.MergeIntMap(ctx, next, path, orig, mergeData)
 }
 
-func (m *UpdateMerger) MergeIntMap(next Merger, path []string, orig, mergeData map[int]any) (map[int]any, error) {
-	return updateMergeMap(next, path, orig, mergeData)
+func (m *UpdateMerger) MergeAnyMap(ctx *Context, next Merger, path []string, orig, mergeData map[any]any) (map[any]any, error) {
+	return MapUpdate.MergeAnyMap(ctx, next, path, orig, mergeData)
 }
@@ -0,0 +1,71 @@
+package merge
+
+// NullPolicy decides what an explicit nil in merge data means.
+type NullPolicy int
+
+const (
+	// NullDefault keeps the behavior of each mode: nil merged into a
+	// container leaves it as is, primitives follow the mode (replace sets
+	// nil, insert and update treat it like any other value).
+	NullDefault NullPolicy = iota
+	// NullIgnore skips nil values in merge data, nothing is set or added.
+	NullIgnore
+	// NullSet sets the value to nil, whatever it was and whatever the mode.
+	NullSet
+	// NullDelete removes map keys and array elements merged with nil.
+	// Array elements are removed after merging, so indexes in merge data
+	// refer to the original positions.
+	NullDelete
+)
+
+// WithNullPolicy sets how nil values in merge data are applied.
+func WithNullPolicy(p NullPolicy) Option {
+	return func(o *Options) { o.NullPolicy = p }
+}
+
+type removal struct{}
+
+// removed is returned by UseMerger for values that have to be removed from
+// their parent container, parents drop them with storeKey and dropRemoved.
+var removed any = removal{}
+
+// applyNull handles a nil merged into orig under policies other than
+// NullDefault. It reports false when the mode has to decide.
+func (c *Context) applyNull(orig any) (any, bool) {
+	switch c.opts.NullPolicy {
+	case NullIgnore:
+		return orig, true
+	case NullSet:
+		return nil, true
+	case NullDelete:
+		return removed, true
+	}
+	return nil, false
+}
+
+// keepNew reports whether a merge data value without a counterpart in orig
+// gets added to it.
+func (c *Context) keepNew(v any) bool {
+	if v != nil {
+		return true
+	}
+	return c.opts.NullPolicy == NullDefault || c.opts.NullPolicy == NullSet
+}
+
+func storeKey[K comparable](m map[K]any, k K, v any) {
+	if v == removed {
+		delete(m, k)
+		return
+	}
+	m[k] = v
+}
+
+func dropRemoved(arr []any) []any {
+	out := arr[:0]
+	for _, v := range arr {
+		if v != removed {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,185 @@
+package merge_test
+
+import (
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+var allModes = []struct {
+	name string
+	mode merge.Mode
+	adds bool // adds keys missing in orig
+}{
+	{"replace", merge.ModeFullReplace, true},
+	{"replace_p", merge.ModePartialReplace, false},
+	{"insert", merge.ModeInsert, true},
+	{"append", merge.ModeAppend, true},
+	{"update", merge.ModeUpdate, false},
+	{"set", merge.ModeSet, true},
+}
+
+func nullOrig() map[string]any {
+	return M("p", 1, "m", M("x", 1), "a", []any{1, 2, 3})
+}
+
+func nullMerge() map[string]any {
+	return M("p", nil, "m", nil, "a", map[int]any{1: nil}, "n", nil)
+}
+
+func TestNullPolicy_Matrix(t *testing.T) {
+	policies := []struct {
+		name   string
+		policy merge.NullPolicy
+		// expected result for modes that add (true) and don't add (false) keys
+		expected func(adds bool) map[string]any
+	}{
+		{
+			name:   "ignore",
+			policy: merge.NullIgnore,
+			expected: func(bool) map[string]any {
+				return nullOrig()
+			},
+		},
+		{
+			name:   "set",
+			policy: merge.NullSet,
+			expected: func(adds bool) map[string]any {
+				res := M("p", nil, "m", nil, "a", []any{1, nil, 3})
+				if adds {
+					res["n"] = nil
+				}
+				return res
+			},
+		},
+		{
+			name:   "delete",
+			policy: merge.NullDelete,
+			expected: func(bool) map[string]any {
+				return M("a", []any{1, 3})
+			},
+		},
+	}
+
+	var cases []TestCase
+	for _, p := range policies {
+		for _, m := range allModes {
+			expected := p.expected(m.adds)
+			data := nullMerge()
+			if m.mode == merge.ModeAppend {
+				// append ignores sparse indexes, covered separately
+				delete(data, "a")
+				expected["a"] = []any{1, 2, 3}
+			}
+			cases = append(cases, TestCase{
+				Name:     p.name + "/" + m.name,
+				Mode:     m.mode,
+				Original: nullOrig(),
+				Merge:    data,
+				Options:  []merge.Option{merge.WithNullPolicy(p.policy)},
+				Expected: expected,
+			})
+		}
+	}
+
+	TableTest(t, cases)
+}
+
+func TestNullPolicy_Default(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "replace sets primitives, keeps containers",
+			Mode:     merge.ModeFullReplace,
+			Original: nullOrig(),
+			Merge:    nullMerge(),
+			Expected: M("p", nil, "m", M("x", 1), "a", []any{1, nil, 3}, "n", nil),
+		},
+		{
+			Name:     "insert keeps set values",
+			Mode:     merge.ModeInsert,
+			Original: nullOrig(),
+			Merge:    nullMerge(),
+			Expected: M("p", 1, "m", M("x", 1), "a", []any{1, 2, 3}, "n", nil),
+		},
+		{
+			Name:     "update sets primitives, keeps containers",
+			Mode:     merge.ModeUpdate,
+			Original: nullOrig(),
+			Merge:    nullMerge(),
+			Expected: M("p", nil, "m", M("x", 1), "a", []any{1, nil, 3}),
+		},
+		{
+			Name:     "nil sparse array merges nothing",
+			Mode:     merge.ModeFullReplace,
+			Original: []any{1, 2},
+			Merge:    map[int]any(nil),
+			Expected: []any{1, 2},
+		},
+	}
+
+	TableTest(t, cases)
+}
+
+func TestNullPolicy_Arrays(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "delete by index refers to original positions",
+			Mode:     merge.ModeFullReplace,
+			Original: []any{"a", "b", "c", "d"},
+			Merge:    []any{nil, "B", nil},
+			Options:  []merge.Option{merge.WithNullPolicy(merge.NullDelete)},
+			// "d" is past the end of merge data, full replace truncates it
+			Expected: []any{"B"},
+		},
+		{
+			Name:     "partial delete by index keeps the tail",
+			Mode:     merge.ModePartialReplace,
+			Original: []any{"a", "b", "c", "d"},
+			Merge:    []any{nil, "B", nil},
+			Options:  []merge.Option{merge.WithNullPolicy(merge.NullDelete)},
+			Expected: []any{"B", "d"},
+		},
+		{
+			Name:     "ignore skips appended nils",
+			Mode:     merge.ModeAppend,
+			Original: []any{1},
+			Merge:    []any{nil, 2},
+			Options:  []merge.Option{merge.WithNullPolicy(merge.NullIgnore)},
+			Expected: []any{1, 2},
+		},
+		{
+			Name:     "set appends nils",
+			Mode:     merge.ModeAppend,
+			Original: []any{1},
+			Merge:    map[int]any{5: nil},
+			Options:  []merge.Option{merge.WithNullPolicy(merge.NullSet)},
+			Expected: []any{1, nil},
+		},
+		{
+			Name:     "delete in int maps",
+			Mode:     merge.ModeInsert,
+			Original: map[int]any{1: "a", 2: "b"},
+			Merge:    map[int]any{1: nil, 3: nil},
+			Options:  []merge.Option{merge.WithNullPolicy(merge.NullDelete)},
+			Expected: map[int]any{2: "b"},
+		},
+		{
+			Name:     "delete in keyed maps",
+			Mode:     merge.ModeUpdate,
+			Original: map[int64]any{1: "a", 2: "b"},
+			Merge:    map[int64]any{1: nil},
+			Options:  []merge.Option{merge.WithNullPolicy(merge.NullDelete)},
+			Expected: map[int64]any{2: "b"},
+		},
+		{
+			Name:     "delete root",
+			Mode:     merge.ModeInsert,
+			Original: M("a", 1),
+			Merge:    nil,
+			Options:  []merge.Option{merge.WithNullPolicy(merge.NullDelete)},
+			Expected: nil,
+		},
+	}
+
+	TableTest(t, cases)
+}
@@ -0,0 +1,165 @@
+package merge_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestNumericModes(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "Sum keeps int type",
+			Mode:     merge.ModeSum,
+			Original: M("requests", 10, "errors", 1),
+			Merge:    M("requests", 5, "latency", 2.5),
+			Expected: M("requests", 15, "errors", 1, "latency", 2.5),
+		},
+		{
+			Name:     "Sum mixed types as float64",
+			Mode:     merge.ModeSum,
+			Original: M("a", 1),
+			Merge:    M("a", 0.5),
+			Expected: M("a", 1.5),
+		},
+		{
+			Name:     "Sum arrays element by element",
+			Mode:     merge.ModeSum,
+			Original: M("buckets", []any{1, 2}),
+			Merge:    M("buckets", []any{10, 20, 30}),
+			Expected: M("buckets", []any{11, 22, 30}),
+		},
+		{
+			Name:     "Sum sparse arrays",
+			Mode:     merge.ModeSum,
+			Original: []any{1, 2, 3},
+			Merge:    map[int]any{1: 5},
+			Expected: []any{1, 7, 3},
+		},
+		{
+			Name:     "Max",
+			Mode:     merge.ModeMax,
+			Original: M("cpu", 2, "mem", 512.0),
+			Merge:    M("cpu", 4, "mem", 256),
+			Expected: M("cpu", 4, "mem", 512.0),
+		},
+		{
+			Name:     "Min",
+			Mode:     merge.ModeMin,
+			Original: M("cpu", 2, "mem", 512.0),
+			Merge:    M("cpu", 4, "mem", 256),
+			Expected: M("cpu", 2, "mem", 256),
+		},
+		{
+			Name:     "Nil merge data keeps the number",
+			Mode:     merge.ModeSum,
+			Original: M("a", 1),
+			Merge:    M("a", nil),
+			Expected: M("a", 1),
+		},
+		{
+			Name:      "Non numeric fails by default",
+			Mode:      merge.ModeSum,
+			Original:  M("name", "a"),
+			Merge:     M("name", "b"),
+			ShouldErr: true,
+			ErrMsg:    "value is not a number: string and string",
+		},
+		{
+			Name:     "Non numeric kept",
+			Mode:     merge.ModeSum,
+			Original: M("name", "a", "n", 1),
+			Merge:    M("name", "b", "n", 2),
+			Options:  []merge.Option{merge.WithNonNumeric(merge.NonNumericKeep)},
+			Expected: M("name", "a", "n", 3),
+		},
+		{
+			Name:     "Non numeric replaced",
+			Mode:     merge.ModeMax,
+			Original: M("name", "a", "n", 1),
+			Merge:    M("name", "b", "n", 2),
+			Options:  []merge.Option{merge.WithNonNumeric(merge.NonNumericReplace)},
+			Expected: M("name", "b", "n", 2),
+		},
+	}
+
+	TableTest(t, cases)
+}
+
+func TestNumericModes_Avg(t *testing.T) {
+	res, err := merge.Bulk(M("latency", 10),
+		merge.ModeDataPair{Mode: merge.ModeAvg, Data: M("latency", 20)},
+		merge.ModeDataPair{Mode: merge.ModeAvg, Data: M("latency", 30, "new", 1)},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := M("latency", 20, "new", 1); !reflect.DeepEqual(res, want) {
+		t.Errorf("got %v, expected %v", toJSON(res), toJSON(want))
+	}
+
+	// counts carried over between calls
+	counts := map[string]int{}
+	res, err = merge.Data(merge.ModeAvg, M("x", 1.0), M("x", 3.0), merge.WithAvgCounts(counts))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, err = merge.Data(merge.ModeAvg, res, M("x", 8.0), merge.WithAvgCounts(counts))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := M("x", 4.0); !reflect.DeepEqual(res, want) {
+		t.Errorf("got %v, expected %v", toJSON(res), toJSON(want))
+	}
+	if counts["x"] != 3 {
+		t.Errorf("got count %d, expected 3", counts["x"])
+	}
+
+	_, err = merge.Data(merge.ModeAvg, M("x", 1), M("x", true))
+	if !errors.Is(err, merge.ErrNotNumeric) {
+		t.Errorf("expected ErrNotNumeric, got %v", err)
+	}
+}
+
+func TestNumericModes_AvgKeepsType(t *testing.T) {
+	res, err := merge.Data(merge.ModeAvg, map[string]int{"x": 1}, map[string]int{"x": 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]int{"x": 2}; !reflect.DeepEqual(res, want) {
+		t.Errorf("got %v, expected %v", res, want)
+	}
+
+	res, err = merge.Bulk(M(),
+		merge.ModeDataPair{Mode: merge.ModeAvg, Data: M("f", float32(1), "i", 1)},
+		merge.ModeDataPair{Mode: merge.ModeAvg, Data: M("f", float32(2), "i", 4)},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := M("f", float32(1.5), "i", 3); !reflect.DeepEqual(res, want) {
+		t.Errorf("got %#v, expected %#v", res, want)
+	}
+}
+
+func TestNumericModes_AvgCounts(t *testing.T) {
+	counts := map[string]int{}
+	res, err := merge.BulkWith(M("a.b", 1.0, "a", M("b", 1.0)), []merge.ModeDataPair{
+		{Mode: merge.ModeAvg, Data: M("a.b", 3.0)},
+		{Mode: merge.ModeAvg, Data: M("a", M("b", 3.0))},
+		{Mode: merge.ModeAvg, Data: M("a", M("b", 100.0)), Policy: merge.AllowPaths("a.b.c")},
+		{Mode: merge.ModeAvg, Data: M("a", M("b", 5.0))},
+	}, merge.WithAvgCounts(counts), merge.WithCollectErrors())
+	var perr *merge.PolicyError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected the third layer to be rejected, got %v", err)
+	}
+	if want := M("a.b", 2.0, "a", M("b", 3.0)); !reflect.DeepEqual(res, want) {
+		t.Errorf("got %v, expected %v", toJSON(res), toJSON(want))
+	}
+	if want := map[string]int{`a\.b`: 2, "a.b": 3}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("got counts %v, expected %v", counts, want)
+	}
+}
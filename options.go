@@ -0,0 +1,225 @@
+package merge
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Options tune a single Data or Bulk call. They're set with Option
+// functions and reach custom mergers through Context.
+type Options struct {
+	// Strict reports primitives of different types as a type mismatch
+	// instead of letting the mode pick one of them.
+	Strict bool
+	// Copy deep copies orig and merge data first, so neither of them is
+	// modified and the result shares no containers with them.
+	Copy bool
+	// CollectErrors keeps merging past failed nodes (leaving them as in
+	// orig) and returns all errors joined together with the result.
+	CollectErrors bool
+	// ZeroPolicy decides which original values count as unset: insert
+	// only fills those and update never touches them. ZeroGo when nil.
+	ZeroPolicy ZeroPolicy
+	// NullPolicy decides what nil values in merge data do.
+	NullPolicy NullPolicy
+	// Arrays overrides how the mode merges two arrays.
+	Arrays ArrayStrategy
+	// ArrayKey identifies array elements, see WithArrayKey.
+	ArrayKey KeyFunc
+	// Customizer is consulted at every node before the mode.
+	Customizer Customizer
+	// Include and Exclude are path patterns restricting what merge
+	// data may touch, see WithInclude.
+	Include []string
+	Exclude []string
+	// OnSkip is called for paths skipped by Include and Exclude.
+	OnSkip func(path Path)
+	// FilterErrors fails with ErrFiltered instead of skipping.
+	FilterErrors bool
+	// Protected are path patterns merge data must not change,
+	// see WithProtected.
+	Protected []string
+	// Policy is checked for every change of every layer.
+	Policy Policy
+	// ArrayPasses post-process arrays once the merge is done.
+	ArrayPasses []ArrayPass
+	// NonNumeric decides what numeric modes do with other values.
+	NonNumeric NonNumeric
+	// AvgCounts are the numbers of values averaged so far by ModeAvg.
+	AvgCounts map[string]int
+	// Primitives override how the mode merges primitives at some paths.
+	Primitives []PrimitiveRule
+}
+
+type Option func(*Options)
+
+// ZeroPolicy reports whether a value of orig counts as unset.
+type ZeroPolicy func(v any) bool
+
+var (
+	// ZeroGo treats Go zero values as unset: nil, "", 0, false and
+	// empty containers. It's the default.
+	ZeroGo ZeroPolicy = isZeroValue
+	// ZeroNil treats only nil as unset, so explicit false, 0 and ""
+	// survive insert and can be changed by update.
+	ZeroNil ZeroPolicy = func(v any) bool { return v == nil }
+	// ZeroPresent never treats a value as unset: a key present in orig
+	// means the value is set, even if it's nil.
+	ZeroPresent ZeroPolicy = func(any) bool { return false }
+)
+
+func WithStrict() Option {
+	return func(o *Options) { o.Strict = true }
+}
+
+func WithCopy() Option {
+	return func(o *Options) { o.Copy = true }
+}
+
+func WithCollectErrors() Option {
+	return func(o *Options) { o.CollectErrors = true }
+}
+
+// WithZeroPolicy sets the policy for unset values, any predicate
+// works as a custom policy.
+func WithZeroPolicy(p ZeroPolicy) Option {
+	return func(o *Options) { o.ZeroPolicy = p }
+}
+
+// WithArrayStrategy merges arrays with s whatever the mode,
+// e.g. WithArrayStrategy(ArrayUnique). It replaces the merger of the mode
+// inside the engine's middlewares, mergers passed to UseMerger directly
+// don't apply it.
+func WithArrayStrategy(s ArrayStrategy) Option {
+	return func(o *Options) { o.Arrays = s }
+}
+
+// Context carries the options and state of a single Data or Bulk call
+// through the recursion. Custom mergers should pass it on to UseMerger.
+type Context struct {
+	opts       Options
+	engine     *Engine
+	errs       []error
+	include    [][]string
+	exclude    [][]string
+	protected  [][]string
+	layer      int
+	label      string
+	policy     Policy
+	ownPruned  map[string]bool
+	passes     [][]string
+	passEach   bool
+	avgCounts  map[string]int
+	avgPending map[string]int
+	primitives [][]string
+}
+
+// NewContext returns a context for calling UseMerger directly.
+// A nil engine means the default one.
+func NewContext(e *Engine, opts ...Option) *Context {
+	if e == nil {
+		e = defaultEngine
+	}
+	ctx := &Context{engine: e}
+	for _, opt := range opts {
+		opt(&ctx.opts)
+	}
+	ctx.include = parsePatterns(ctx.opts.Include)
+	ctx.exclude = parsePatterns(ctx.opts.Exclude)
+	ctx.protected = parsePatterns(ctx.opts.Protected)
+	passes := make([]string, len(ctx.opts.ArrayPasses))
+	for i, pass := range ctx.opts.ArrayPasses {
+		passes[i] = pass.Pattern
+	}
+	ctx.passes = parsePatterns(passes)
+	rules := make([]string, len(ctx.opts.Primitives))
+	for i, rule := range ctx.opts.Primitives {
+		rules[i] = rule.Pattern
+	}
+	ctx.primitives = parsePatterns(rules)
+	ctx.avgCounts = ctx.opts.AvgCounts
+	if ctx.avgCounts == nil {
+		ctx.avgCounts = make(map[string]int)
+	}
+	ctx.avgPending = make(map[string]int)
+	return ctx
+}
+
+// Options returns the options of the call.
+func (c *Context) Options() Options {
+	return c.opts
+}
+
+// Engine returns the engine the call runs on.
+func (c *Context) Engine() *Engine {
+	return c.engine
+}
+
+// IsZero reports whether v counts as unset under the zero policy.
+func (c *Context) IsZero(v any) bool {
+	if c == nil || c.opts.ZeroPolicy == nil {
+		return isZeroValue(v)
+	}
+	return c.opts.ZeroPolicy(v)
+}
+
+// Err returns the errors collected with CollectErrors.
+func (c *Context) Err() error {
+	return errors.Join(c.errs...)
+}
+
+// fail either returns err or, when collecting errors,
+// records it and keeps orig in place.
+func (c *Context) fail(orig any, err error) (any, error) {
+	if !c.opts.CollectErrors {
+		return nil, err
+	}
+	c.errs = append(c.errs, err)
+	return orig, nil
+}
+
+// deepCopy copies maps and slices of a merge tree, primitives are
+// returned as is.
+func deepCopy(v any) any {
+	switch c := v.(type) {
+	case map[string]any:
+		return copyMap(c)
+	case map[int]any:
+		return copyMap(c)
+	case []any:
+		if c == nil {
+			return c
+		}
+		out := make([]any, len(c))
+		for i, elem := range c {
+			out[i] = deepCopy(elem)
+		}
+		return out
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.IsNil() {
+		return v
+	}
+	out := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		val := iter.Value()
+		if cp := deepCopy(val.Interface()); cp != nil {
+			val = reflect.ValueOf(cp)
+		}
+		out.SetMapIndex(iter.Key(), val)
+	}
+	return out.Interface()
+}
+
+func copyMap[K comparable](m map[K]any) map[K]any {
+	if m == nil {
+		return m
+	}
+	out := make(map[K]any, len(m))
+	for k, v := range m {
+		out[k] = deepCopy(v)
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package merge_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestOptions_Strict(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "lenient primitives of different types",
+			Mode:     merge.ModeFullReplace,
+			Original: M("port", 80),
+			Merge:    M("port", "http"),
+			Expected: M("port", "http"),
+		},
+		{
+			Name:      "strict primitives of different types",
+			Mode:      merge.ModeFullReplace,
+			Original:  M("port", 80),
+			Merge:     M("port", "http"),
+			Options:   []merge.Option{merge.WithStrict()},
+			ShouldErr: true,
+			ErrMsg:    "expected int, got string",
+		},
+		{
+			Name:     "strict allows nil",
+			Mode:     merge.ModeFullReplace,
+			Original: M("port", nil),
+			Merge:    M("port", 80),
+			Options:  []merge.Option{merge.WithStrict()},
+			Expected: M("port", 80),
+		},
+	}
+
+	TableTest(t, cases)
+}
+
+func TestOptions_Copy(t *testing.T) {
+	orig := M("db", M("host", "a"), "tags", []any{"x"})
+	data := M("db", M("port", 1), "extra", M("k", "v"))
+
+	res, err := merge.Data(merge.ModeInsert, orig, data, merge.WithCopy())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := M("db", M("host", "a"), "tags", []any{"x"}); !reflect.DeepEqual(orig, want) {
+		t.Errorf("orig modified: %s", toJSON(orig))
+	}
+	res.(map[string]any)["extra"].(map[string]any)["k"] = "changed"
+	if data["extra"].(map[string]any)["k"] != "v" {
+		t.Error("result shares containers with merge data")
+	}
+}
+
+func TestOptions_CollectErrors(t *testing.T) {
+	orig := M("a", M("x", 1), "b", []any{1}, "c", 1)
+	data := M("a", []any{2}, "b", M("y", 2), "c", 2)
+
+	res, err := merge.Data(merge.ModeFullReplace, orig, data, merge.WithCollectErrors())
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	if n := strings.Count(err.Error(), "merge error at"); n != 2 {
+		t.Errorf("expected 2 collected errors, got %d: %v", n, err)
+	}
+	if want := M("a", M("x", 1), "b", []any{1}, "c", 2); !reflect.DeepEqual(res, want) {
+		t.Errorf("Result mismatch:\nGot:      %s\nExpected: %s", toJSON(res), toJSON(want))
+	}
+
+	if _, err := merge.Data(merge.ModeFullReplace, M("a", M()), M("a", 1)); err == nil || errors.Unwrap(err) == nil {
+		t.Errorf("expected single wrapped error without collecting, got %v", err)
+	}
+}
+
+func TestOptions_CollectErrors_UnknownMode(t *testing.T) {
+	res, err := merge.BulkWith(M("a", 1), []merge.ModeDataPair{
+		{Mode: 999, Data: M("b", 2)},
+		{Mode: merge.ModeInsert, Data: M("c", 3)},
+	}, merge.WithCollectErrors())
+	if err == nil || !strings.Contains(err.Error(), "mode 999") {
+		t.Errorf("expected unknown mode error, got %v", err)
+	}
+	if want := M("a", 1, "c", 3); !reflect.DeepEqual(res, want) {
+		t.Errorf("Result mismatch:\nGot:      %s\nExpected: %s", toJSON(res), toJSON(want))
+	}
+}
+
+func TestOptions_Bulk(t *testing.T) {
+	orig := M("a", 1)
+	res, err := merge.BulkWith(orig, []merge.ModeDataPair{
+		{Mode: merge.ModeInsert, Data: M("b", 2)},
+		{Mode: merge.ModeUpdate, Data: M("a", 3)},
+	}, merge.WithCopy())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := M("a", 3, "b", 2); !reflect.DeepEqual(res, want) {
+		t.Errorf("Result mismatch:\nGot:      %s\nExpected: %s", toJSON(res), toJSON(want))
+	}
+	if !reflect.DeepEqual(orig, M("a", 1)) {
+		t.Errorf("orig modified: %s", toJSON(orig))
+	}
+}
@@ -0,0 +1,208 @@
+package merge
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// overlaySeg is a path segment of overlay merge data (env, flags).
+// Index is used when the segment addresses an element of a []any.
+type overlaySeg struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// resolvePath matches raw segments against shape and returns them
+// along with the shape value found at the end of the path (nil if none).
+func resolvePath(shape any, raw []string) ([]overlaySeg, any, error) {
+	segs := make([]overlaySeg, len(raw))
+	for i, r := range raw {
+		if r == "" {
+			return nil, nil, fmt.Errorf("empty path segment")
+		}
+
+		switch s := shape.(type) {
+		case []any:
+			idx, err := strconv.Atoi(r)
+			if err != nil || idx < 0 {
+				return nil, nil, fmt.Errorf("segment %q: expected array index", r)
+			}
+			segs[i] = overlaySeg{Index: idx, IsIndex: true}
+			shape = nil
+			if idx < len(s) {
+				shape = s[idx]
+			}
+
+		case map[string]any:
+			key, found, err := lookupKey(s, r)
+			if err != nil {
+				return nil, nil, err
+			}
+			segs[i] = overlaySeg{Key: key}
+			shape = nil
+			if found {
+				shape = s[key]
+			}
+
+		default:
+			segs[i] = overlaySeg{Key: strings.ToLower(r)}
+			shape = nil
+		}
+	}
+	return segs, shape, nil
+}
+
+// lookupKey finds the key of m matching key: an exact match first, then
+// the only key equal to it ignoring case. Keys differing only by case are
+// ambiguous unless one of them matches exactly. Missing keys are lowercased.
+func lookupKey(m map[string]any, key string) (string, bool, error) {
+	if _, found := m[key]; found {
+		return key, true, nil
+	}
+	var matches []string
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			matches = append(matches, k)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return strings.ToLower(key), false, nil
+	case 1:
+		return matches[0], true, nil
+	}
+	sort.Strings(matches)
+	return "", false, fmt.Errorf("segment %q: ambiguous, matches keys %q", key, matches)
+}
+
+// setOverlay stores v at segs in root, creating map[string]any and
+// map[int]any containers on the way.
+func setOverlay(root map[string]any, segs []overlaySeg, v any) error {
+	var cur any = root
+	for i, seg := range segs {
+		last := i == len(segs)-1
+
+		var next any
+		if !last {
+			if segs[i+1].IsIndex {
+				next = map[int]any{}
+			} else {
+				next = map[string]any{}
+			}
+		}
+
+		switch c := cur.(type) {
+		case map[string]any:
+			if last {
+				if _, exists := c[seg.Key]; exists {
+					return fmt.Errorf("conflicting value at %v", pathOf(segs[:i+1]))
+				}
+				c[seg.Key] = v
+				return nil
+			}
+			if existing, exists := c[seg.Key]; exists {
+				next = existing
+			} else {
+				c[seg.Key] = next
+			}
+
+		case map[int]any:
+			if last {
+				if _, exists := c[seg.Index]; exists {
+					return fmt.Errorf("conflicting value at %v", pathOf(segs[:i+1]))
+				}
+				c[seg.Index] = v
+				return nil
+			}
+			if existing, exists := c[seg.Index]; exists {
+				next = existing
+			} else {
+				c[seg.Index] = next
+			}
+
+		default:
+			return fmt.Errorf("conflicting value at %v", pathOf(segs[:i]))
+		}
+		cur = next
+	}
+	return nil
+}
+
+func pathOf(segs []overlaySeg) Path {
+	p := make(Path, len(segs))
+	for i, seg := range segs {
+		if seg.IsIndex {
+			p[i] = strconv.Itoa(seg.Index)
+		} else {
+			p[i] = seg.Key
+		}
+	}
+	return p
+}
+
+// coerceString parses s into the type of shape. Containers are decoded
+// from JSON, a nil shape leaves s as is.
+func coerceString(shape any, s string) (any, error) {
+	if shape == nil {
+		return s, nil
+	}
+
+	switch shape.(type) {
+	case map[string]any, []any:
+		var v any
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("expected JSON %T: %w", shape, err)
+		}
+		if reflect.TypeOf(v) != reflect.TypeOf(shape) {
+			return nil, fmt.Errorf("expected JSON %T, got %T", shape, v)
+		}
+		return v, nil
+	}
+
+	t := reflect.TypeOf(shape)
+	out := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		out.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, err
+		}
+		out.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 0, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		out.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 0, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		out.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		out.SetFloat(f)
+	default:
+		return s, nil
+	}
+	return out.Interface(), nil
+}
+
+func compactJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
@@ -0,0 +1,100 @@
+package merge
+
+import "sort"
+
+// ArrayPass post-processes the arrays at paths matching Pattern once the
+// merge is done: after Data, or after the last layer of Bulk. See
+// WithInclude for the pattern syntax, patterns match whole paths.
+type ArrayPass struct {
+	Pattern string
+	Apply   func(arr []any) []any
+}
+
+// WithArrayPass runs fn on the arrays at paths matching pattern once the
+// merge is done, or after every layer under protected paths or policies.
+// Passes run in the order they're given, nested arrays before the ones
+// containing them.
+func WithArrayPass(pattern string, fn func(arr []any) []any) Option {
+	return func(o *Options) {
+		o.ArrayPasses = append(o.ArrayPasses, ArrayPass{Pattern: pattern, Apply: fn})
+	}
+}
+
+// WithSort stable sorts the arrays at paths matching pattern with less.
+func WithSort(pattern string, less func(a, b any) bool) Option {
+	return WithArrayPass(pattern, func(arr []any) []any {
+		out := make([]any, len(arr))
+		copy(out, arr)
+		sort.SliceStable(out, func(i, j int) bool { return less(out[i], out[j]) })
+		return out
+	})
+}
+
+// WithSortBy stable sorts the arrays at paths matching pattern by key
+// in ascending order. Numbers compare numerically, strings
+// lexicographically. A nil key sorts by the elements themselves.
+func WithSortBy(pattern string, key KeyFunc) Option {
+	if key == nil {
+		key = func(v any) any { return v }
+	}
+	return WithSort(pattern, func(a, b any) bool {
+		return compareValues(key(a), key(b)) < 0
+	})
+}
+
+// WithDedupe drops elements of the arrays at paths matching pattern whose
+// key is deeply equal to one of an earlier element. A nil key compares
+// the elements themselves.
+func WithDedupe(pattern string, key KeyFunc) Option {
+	if key == nil {
+		key = func(v any) any { return v }
+	}
+	return WithArrayPass(pattern, func(arr []any) []any {
+		out := make([]any, 0, len(arr))
+		keys := make([]any, 0, len(arr))
+		for _, v := range arr {
+			k := key(v)
+			if !contains(keys, k) {
+				out = append(out, v)
+				keys = append(keys, k)
+			}
+		}
+		return out
+	})
+}
+
+// finish runs the array passes over the merge result. Under protected
+// paths or policies they ran after every layer instead, before its
+// changes were checked, so passes can't make changes a layer may not.
+func (c *Context) finish(v any) any {
+	if len(c.opts.ArrayPasses) == 0 || c.passEach {
+		return v
+	}
+	return c.runPasses(nil, v)
+}
+
+func (c *Context) runPasses(path []string, v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		runMapPasses(c, path, t)
+	case map[int]any:
+		runMapPasses(c, path, t)
+	case []any:
+		for i, elem := range t {
+			t[i] = c.runPasses(append(path, pathSegment(i)), elem)
+		}
+		for i, pass := range c.opts.ArrayPasses {
+			if matchPattern(c.passes[i], path) {
+				t = pass.Apply(t)
+			}
+		}
+		return t
+	}
+	return v
+}
+
+func runMapPasses[K comparable](c *Context, path []string, m map[K]any) {
+	for k, v := range m {
+		m[k] = c.runPasses(append(path, pathSegment(k)), v)
+	}
+}
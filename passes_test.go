@@ -0,0 +1,110 @@
+package merge_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestArrayPasses(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "Dedupe after append",
+			Mode:     merge.ModeAppend,
+			Original: M("tags", []any{"a", "b"}),
+			Merge:    M("tags", []any{"b", "c", "a"}),
+			Options:  []merge.Option{merge.WithDedupe("tags", nil)},
+			Expected: M("tags", []any{"a", "b", "c"}),
+		},
+		{
+			Name:     "Sort by value",
+			Mode:     merge.ModeAppend,
+			Original: M("ports", []any{80, 8080}),
+			Merge:    M("ports", []any{443, 22.0}),
+			Options:  []merge.Option{merge.WithSortBy("ports", nil)},
+			Expected: M("ports", []any{22.0, 80, 443, 8080}),
+		},
+		{
+			Name:     "Dedupe and sort by key at any depth",
+			Mode:     merge.ModeAppend,
+			Original: M("a", M("users", []any{M("name", "c"), M("name", "a", "id", 1)})),
+			Merge:    M("a", M("users", []any{M("name", "a", "id", 2), M("name", "b")})),
+			Options: []merge.Option{
+				merge.WithDedupe("**.users", merge.ByField("name")),
+				merge.WithSortBy("**.users", merge.ByField("name")),
+			},
+			Expected: M("a", M("users", []any{M("name", "a", "id", 1), M("name", "b"), M("name", "c")})),
+		},
+		{
+			Name:     "Custom comparator",
+			Mode:     merge.ModeInsert,
+			Original: M("x", []any{"bb", "a", "ccc"}),
+			Merge:    M(),
+			Options: []merge.Option{merge.WithSort("x", func(a, b any) bool {
+				return len(a.(string)) > len(b.(string))
+			})},
+			Expected: M("x", []any{"ccc", "bb", "a"}),
+		},
+		{
+			Name:     "Other paths untouched",
+			Mode:     merge.ModeAppend,
+			Original: M("x", []any{2, 1}, "y", []any{2, 1}),
+			Merge:    M(),
+			Options:  []merge.Option{merge.WithSortBy("y", nil)},
+			Expected: M("x", []any{2, 1}, "y", []any{1, 2}),
+		},
+	}
+
+	TableTest(t, cases)
+}
+
+func TestArrayPasses_Bulk(t *testing.T) {
+	var calls int
+	res, err := merge.BulkWith(M("tags", []any{"b"}), []merge.ModeDataPair{
+		{Mode: merge.ModeAppend, Data: M("tags", []any{"a"})},
+		{Mode: merge.ModeAppend, Data: M("tags", []any{"b"})},
+	}, merge.WithArrayPass("tags", func(arr []any) []any {
+		calls++
+		return arr
+	}), merge.WithDedupe("tags", nil), merge.WithSortBy("tags", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("pass ran %d times, expected once after the last layer", calls)
+	}
+	if want := M("tags", []any{"a", "b"}); !reflect.DeepEqual(res, want) {
+		t.Errorf("got %v, expected %v", toJSON(res), toJSON(want))
+	}
+}
+
+func TestArrayPasses_Policies(t *testing.T) {
+	sortTags := merge.WithSortBy("tags", nil)
+
+	_, err := merge.Data(merge.ModeInsert, M("tags", []any{"b", "a"}), M("x", 1),
+		merge.WithProtected("tags"), sortTags)
+	var perr *merge.PolicyError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected sorting a protected array to be a PolicyError, got %v", err)
+	}
+
+	res, err := merge.BulkWith(M("tags", []any{"b", "a"}), []merge.ModeDataPair{
+		{Mode: merge.ModeAppend, Data: M("tags", []any{"c"})},
+		{Mode: merge.ModeInsert, Data: M("x", 1), Policy: merge.AllowPaths("x")},
+	}, sortTags)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := M("tags", []any{"a", "b", "c"}, "x", 1); !reflect.DeepEqual(res, want) {
+		t.Errorf("got %v, expected %v", toJSON(res), toJSON(want))
+	}
+
+	_, err = merge.BulkWith(M("tags", []any{"b", "a"}), []merge.ModeDataPair{
+		{Mode: merge.ModeInsert, Data: M("x", 1), Policy: merge.AllowPaths("x")},
+	}, sortTags)
+	if !errors.As(err, &perr) || perr.Layer != 0 {
+		t.Errorf("expected layer 0 to be rejected for sorting tags, got %v", err)
+	}
+}
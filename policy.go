@@ -0,0 +1,252 @@
+package merge
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+var (
+	// ErrProtected is wrapped by the PolicyError of a change to a protected path.
+	ErrProtected = errors.New("path is protected")
+	// ErrForbidden is returned by the built-in policies for changes they deny.
+	ErrForbidden = errors.New("change is forbidden")
+)
+
+// PolicyError reports a change merge data isn't allowed to make.
+// The merge fails as a whole instead of skipping the change.
+type PolicyError struct {
+	// Layer is the index of the offending layer in Bulk, 0 for Data.
+	Layer int
+	// Label identifies the layer, see ModeDataPair.
+	Label string
+	Path  Path
+	Kind  ChangeKind
+	Err   error
+}
+
+func (e *PolicyError) Error() string {
+	layer := strconv.Itoa(e.Layer)
+	if e.Label != "" {
+		layer += " (" + e.Label + ")"
+	}
+	return fmt.Sprintf("layer %s: %s %s: %v", layer, e.Kind, e.Path, e.Err)
+}
+
+func (e *PolicyError) Unwrap() error {
+	return e.Err
+}
+
+// WithProtected makes paths matching any of patterns, and everything
+// below them, read-only: merge data changing them fails the call with a
+// PolicyError. See WithInclude for the pattern syntax.
+func WithProtected(patterns ...string) Option {
+	return func(o *Options) { o.Protected = append(o.Protected, patterns...) }
+}
+
+// Policy decides whether the layer labeled label may make the change ch:
+// add, modify or remove the value at ch.Path. Returning an error rejects
+// the layer, the error is wrapped in a PolicyError.
+//
+// Changes are the ones reported by Diff between the layer's input and its
+// result, so a change at a path may replace a whole subtree.
+type Policy func(label string, ch Change) error
+
+// WithPolicy checks every layer against p, on top of the layer's own policy.
+func WithPolicy(p Policy) Option {
+	return func(o *Options) { o.Policy = p }
+}
+
+// AllowPaths allows changes to paths matching any of patterns and
+// everything below them, see WithInclude for the pattern syntax. A change
+// above them is allowed when it only adds or removes values they cover.
+func AllowPaths(patterns ...string) Policy {
+	pats := parsePatterns(patterns)
+	covered := func(path []string) bool {
+		for _, pat := range pats {
+			if coversPath(pat, path) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(_ string, ch Change) error {
+		if covered(ch.Path) {
+			return nil
+		}
+		ok := false
+		for _, pat := range pats {
+			ok = ok || matchesBelow(pat, ch.Path)
+		}
+		for _, v := range []any{ch.Old, ch.New} {
+			if !ok {
+				break
+			}
+			if v != nil && !isBranch(v) {
+				ok = false
+			}
+			walk(ch.Path, v, func(p Path, v any) {
+				ok = ok && (isBranch(v) || covered(p))
+			})
+		}
+		if !ok {
+			return ErrForbidden
+		}
+		return nil
+	}
+}
+
+// AllowActions allows only the given kinds of changes,
+// e.g. AllowActions(Added) for layers that may only add values.
+func AllowActions(kinds ...ChangeKind) Policy {
+	return func(_ string, ch Change) error {
+		if !slices.Contains(kinds, ch.Kind) {
+			return ErrForbidden
+		}
+		return nil
+	}
+}
+
+// Policies combines policies, a change has to be allowed by all of them.
+func Policies(policies ...Policy) Policy {
+	return func(label string, ch Change) error {
+		for _, p := range policies {
+			if err := p(label, ch); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Layer returns the index of the Bulk layer being merged, 0 for Data.
+func (c *Context) Layer() int {
+	return c.layer
+}
+
+// Label returns the label of the Bulk layer being merged.
+func (c *Context) Label() string {
+	return c.label
+}
+
+// guarded reports whether layers have to be checked against policies.
+func (c *Context) guarded() bool {
+	return len(c.protected) > 0 || c.opts.Policy != nil || c.policy != nil
+}
+
+// checkPolicy compares a layer's input with its result and returns the
+// changes it wasn't allowed to make.
+func (c *Context) checkPolicy(old, new any) error {
+	var errs []error
+	for _, ch := range Diff(old, new) {
+		if c.protects(ch.Path) {
+			errs = append(errs, c.policyError(ch.Path, ch.Kind, ErrProtected))
+			continue
+		}
+		// ch may have replaced a whole subtree with protected paths in it.
+		if path, kind, found := c.protectedBelow(ch); found {
+			errs = append(errs, c.policyError(path, kind, ErrProtected))
+			continue
+		}
+
+		for _, p := range []Policy{c.opts.Policy, c.policy} {
+			if p == nil {
+				continue
+			}
+			if err := p(c.label, ch); err != nil {
+				errs = append(errs, c.policyError(ch.Path, ch.Kind, err))
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Context) policyError(path Path, kind ChangeKind, err error) error {
+	return &PolicyError{Layer: c.layer, Label: c.label, Path: path, Kind: kind, Err: err}
+}
+
+func (c *Context) protects(path []string) bool {
+	for _, pat := range c.protected {
+		if coversPath(pat, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// protectedBelow returns the first protected node found in the old or new
+// subtree of ch and how it changed.
+func (c *Context) protectedBelow(ch Change) (Path, ChangeKind, bool) {
+	below := false
+	for _, pat := range c.protected {
+		below = below || matchesBelow(pat, ch.Path)
+	}
+	if !below {
+		return nil, 0, false
+	}
+
+	var first Path
+	seen := map[bool]map[string]bool{true: {}, false: {}}
+	visit := func(old bool) func(Path, any) {
+		return func(p Path, _ any) {
+			if !c.protects(p) {
+				return
+			}
+			seen[old][p.String()] = true
+			if first == nil || lessPath(p, first) {
+				first = p
+			}
+		}
+	}
+	walk(ch.Path, ch.Old, visit(true))
+	walk(ch.Path, ch.New, visit(false))
+	if first == nil {
+		return nil, 0, false
+	}
+
+	inOld, inNew := seen[true][first.String()], seen[false][first.String()]
+	switch {
+	case inOld && inNew:
+		return first, Modified, true
+	case inNew:
+		return first, Added, true
+	}
+	return first, Removed, true
+}
+
+// walk calls fn for every node below path in v.
+func walk(path Path, v any, fn func(Path, any)) {
+	switch t := v.(type) {
+	case map[string]any:
+		walkMap(path, t, fn)
+	case map[int]any:
+		walkMap(path, t, fn)
+	case []any:
+		for i, elem := range t {
+			p := appendPath(path, strconv.Itoa(i))
+			fn(p, elem)
+			walk(p, elem, fn)
+		}
+	default:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Map {
+			walkMap(path, toAnyMap(rv), fn)
+		}
+	}
+}
+
+func walkMap[K comparable](path Path, m map[K]any, fn func(Path, any)) {
+	for k, v := range m {
+		p := appendPath(path, pathSegment(k))
+		fn(p, v)
+		walk(p, v, fn)
+	}
+}
+
+// isBranch reports whether v is a container walk descends into.
+func isBranch(v any) bool {
+	return isContainer(v) || reflect.ValueOf(v).Kind() == reflect.Map
+}
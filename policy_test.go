@@ -0,0 +1,216 @@
+package merge_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestProtected(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "untouched protected path",
+			Mode:     merge.ModeFullReplace,
+			Original: M("security", M("tls", true), "db", M("host", "a")),
+			Merge:    M("security", M("tls", true), "db", M("host", "b")),
+			Options:  []merge.Option{merge.WithProtected("security")},
+			Expected: M("security", M("tls", true), "db", M("host", "b")),
+		},
+		{
+			Name:      "modified protected leaf",
+			Mode:      merge.ModeFullReplace,
+			Original:  M("security", M("tls", true)),
+			Merge:     M("security", M("tls", false)),
+			Options:   []merge.Option{merge.WithProtected("security.tls")},
+			ShouldErr: true,
+			ErrMsg:    "layer 0: modified security.tls: path is protected",
+		},
+		{
+			Name:      "added below protected path",
+			Mode:      merge.ModeInsert,
+			Original:  M("security", M("tls", true)),
+			Merge:     M("security", M("ciphers", "weak")),
+			Options:   []merge.Option{merge.WithProtected("security")},
+			ShouldErr: true,
+			ErrMsg:    "added security.ciphers",
+		},
+		{
+			Name:      "ancestor deleted",
+			Mode:      merge.ModeFullReplace,
+			Original:  M("security", M("tls", true), "db", 1),
+			Merge:     M("security", nil),
+			Options:   []merge.Option{merge.WithProtected("**.tls"), merge.WithNullPolicy(merge.NullDelete)},
+			ShouldErr: true,
+			ErrMsg:    "removed security.tls",
+		},
+		{
+			Name:     "new sibling of protected path",
+			Mode:     merge.ModeInsert,
+			Original: M("security", M("tls", true)),
+			Merge:    M("security", M("audit", true)),
+			Options:  []merge.Option{merge.WithProtected("security.tls")},
+			Expected: M("security", M("tls", true, "audit", true)),
+		},
+		{
+			Name:     "new subtree without protected paths",
+			Mode:     merge.ModeInsert,
+			Original: nil,
+			Merge:    M("db", M("host", "a")),
+			Options:  []merge.Option{merge.WithProtected("security.tls")},
+			Expected: M("db", M("host", "a")),
+		},
+		{
+			Name:      "new subtree with protected path",
+			Mode:      merge.ModeInsert,
+			Original:  nil,
+			Merge:     M("security", M("tls", false)),
+			Options:   []merge.Option{merge.WithProtected("security.tls")},
+			ShouldErr: true,
+			ErrMsg:    "added security.tls",
+		},
+		{
+			Name:      "protected array element",
+			Mode:      merge.ModeAppend,
+			Original:  M("admins", []any{"root"}),
+			Merge:     M("admins", []any{"mallory"}),
+			Options:   []merge.Option{merge.WithProtected("admins")},
+			ShouldErr: true,
+			ErrMsg:    "added admins.1",
+		},
+	}
+
+	TableTest(t, cases)
+}
+
+func TestProtected_Bulk(t *testing.T) {
+	orig := M("security", M("tls", true), "db", M("host", "a"))
+	layers := []merge.ModeDataPair{
+		{Mode: merge.ModeFullReplace, Data: M("db", M("host", "b"))},
+		{Mode: merge.ModeFullReplace, Data: M("security", M("tls", false))},
+	}
+
+	_, err := merge.BulkWith(orig, layers, merge.WithProtected("security"))
+	var perr *merge.PolicyError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected PolicyError, got %v", err)
+	}
+	if perr.Layer != 1 || perr.Path.String() != "security.tls" || perr.Kind != merge.Modified {
+		t.Errorf("unexpected policy error: %+v", perr)
+	}
+	if !errors.Is(err, merge.ErrProtected) {
+		t.Errorf("expected ErrProtected, got %v", err)
+	}
+	if !reflect.DeepEqual(orig, M("security", M("tls", true), "db", M("host", "a"))) {
+		t.Errorf("orig was modified: %v", toJSON(orig))
+	}
+
+	res, err := merge.BulkWith(M("security", M("tls", true), "db", M("host", "a")), layers,
+		merge.WithProtected("security"), merge.WithCollectErrors())
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected PolicyError, got %v", err)
+	}
+	expected := M("security", M("tls", true), "db", M("host", "b"))
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("got %v, expected %v", toJSON(res), toJSON(expected))
+	}
+}
+
+func TestLayerPolicy(t *testing.T) {
+	teams := merge.AllowPaths("features.*")
+	orig := M("features", M("a", false), "db", M("host", "a"))
+
+	res, err := merge.Bulk(orig,
+		merge.ModeDataPair{Mode: merge.ModeFullReplace, Data: M("db", M("host", "b")), Label: "ops"},
+		merge.ModeDataPair{Mode: merge.ModeFullReplace, Data: M("features", M("a", true, "b", true)), Label: "team", Policy: teams},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := M("features", M("a", true, "b", true), "db", M("host", "b")); !reflect.DeepEqual(res, want) {
+		t.Errorf("got %v, expected %v", toJSON(res), toJSON(want))
+	}
+
+	_, err = merge.Bulk(res,
+		merge.ModeDataPair{Mode: merge.ModeFullReplace, Data: M("db", M("host", "c")), Label: "team", Policy: teams},
+	)
+	var perr *merge.PolicyError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected PolicyError, got %v", err)
+	}
+	if perr.Label != "team" || perr.Path.String() != "db.host" || perr.Kind != merge.Modified {
+		t.Errorf("unexpected policy error: %+v", perr)
+	}
+	if want := "layer 0 (team): modified db.host: change is forbidden"; err.Error() != want {
+		t.Errorf("got %q, expected %q", err, want)
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "allowed paths added with ancestor",
+			Mode:     merge.ModeInsert,
+			Original: M("db", 1),
+			Merge:    M("features", M("a", true)),
+			Options:  []merge.Option{merge.WithPolicy(merge.AllowPaths("features.*"))},
+			Expected: M("db", 1, "features", M("a", true)),
+		},
+		{
+			Name:      "ancestor added with other paths",
+			Mode:      merge.ModeInsert,
+			Original:  M("db", 1),
+			Merge:     M("features", M("a", true), "other", M("b", 1)),
+			Options:   []merge.Option{merge.WithPolicy(merge.AllowPaths("features.*"))},
+			ShouldErr: true,
+			ErrMsg:    "added other: change is forbidden",
+		},
+		{
+			Name:     "allowed paths removed with ancestor",
+			Mode:     merge.ModeFullReplace,
+			Original: M("features", M("a", true)),
+			Merge:    M("features", nil),
+			Options:  []merge.Option{merge.WithPolicy(merge.AllowPaths("features.*")), merge.WithNullPolicy(merge.NullSet)},
+			Expected: M("features", nil),
+		},
+		{
+			Name:      "allowed paths below a primitive",
+			Mode:      merge.ModeFullReplace,
+			Original:  M("features", "off"),
+			Merge:     M("features", nil),
+			Options:   []merge.Option{merge.WithPolicy(merge.AllowPaths("features.*")), merge.WithNullPolicy(merge.NullSet)},
+			ShouldErr: true,
+			ErrMsg:    "modified features",
+		},
+		{
+			Name:     "add only",
+			Mode:     merge.ModeInsert,
+			Original: M("a", 1),
+			Merge:    M("a", 2, "b", 2),
+			Options:  []merge.Option{merge.WithPolicy(merge.AllowActions(merge.Added))},
+			Expected: M("a", 1, "b", 2),
+		},
+		{
+			Name:      "no deletes",
+			Mode:      merge.ModeFullReplace,
+			Original:  M("a", 1, "b", 2),
+			Merge:     M("a", nil),
+			Options:   []merge.Option{merge.WithPolicy(merge.AllowActions(merge.Added, merge.Modified)), merge.WithNullPolicy(merge.NullDelete)},
+			ShouldErr: true,
+			ErrMsg:    "removed a",
+		},
+		{
+			Name:     "combined",
+			Mode:     merge.ModeFullReplace,
+			Original: M("features", M("a", false)),
+			Merge:    M("features", M("a", true)),
+			Options: []merge.Option{merge.WithPolicy(merge.Policies(
+				merge.AllowPaths("features"), merge.AllowActions(merge.Modified),
+			))},
+			Expected: M("features", M("a", true)),
+		},
+	}
+
+	TableTest(t, cases)
+}
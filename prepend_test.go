@@ -0,0 +1,65 @@
+package merge_test
+
+import (
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestPrependMode(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "Prepend array",
+			Mode:     merge.ModePrepend,
+			Original: []any{"b", "c"},
+			Merge:    []any{"a", "c"},
+			Expected: []any{"a", "c", "b", "c"},
+		},
+		{
+			Name:     "Prepend unique moves duplicates to the front",
+			Mode:     merge.ModePrependUnique,
+			Original: []any{"/usr/bin", "/bin", "/opt/bin"},
+			Merge:    []any{"/opt/bin", "/home/me/bin", "/opt/bin"},
+			Expected: []any{"/opt/bin", "/home/me/bin", "/usr/bin", "/bin"},
+		},
+		{
+			Name:     "Prepend sparse array in index order",
+			Mode:     merge.ModePrepend,
+			Original: []any{3},
+			Merge:    map[int]any{7: 2, 1: 1},
+			Expected: []any{1, 2, 3},
+		},
+		{
+			Name:     "Prepend nested arrays, insert maps",
+			Mode:     merge.ModePrepend,
+			Original: M("path", []any{"b"}, "name", "x"),
+			Merge:    M("path", []any{"a"}, "name", "y", "new", 1),
+			Expected: M("path", []any{"a", "b"}, "name", "x", "new", 1),
+		},
+		{
+			Name:     "Prepend into empty array",
+			Mode:     merge.ModePrepend,
+			Original: []any{},
+			Merge:    []any{1},
+			Expected: []any{1},
+		},
+		{
+			Name:     "Prepend ignores nils",
+			Mode:     merge.ModePrepend,
+			Original: []any{2},
+			Merge:    []any{nil, 1},
+			Options:  []merge.Option{merge.WithNullPolicy(merge.NullIgnore)},
+			Expected: []any{1, 2},
+		},
+		{
+			Name:     "Prepend prunes excluded paths of new values only",
+			Mode:     merge.ModePrepend,
+			Original: M("users", []any{M("name", "a", "password", "x")}),
+			Merge:    M("users", []any{M("name", "b", "password", "y")}),
+			Options:  []merge.Option{merge.WithExclude("**.password")},
+			Expected: M("users", []any{M("name", "b"), M("name", "a", "password", "x")}),
+		},
+	}
+
+	TableTest(t, cases)
+}
@@ -0,0 +1,204 @@
+package merge
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Removal removes elements of orig when used as a value of sparse merge
+// data: map[int]any{3: merge.Remove} removes the element at index 3,
+// map[int]any{3: merge.RemoveN(2)} the ones at 3 and 4. Negative indexes
+// count from the end.
+//
+// Removals are applied after everything else in merge data, so their
+// indexes always refer to orig, whatever the mode did to other elements.
+// Markers anywhere else, e.g. in maps or arrays of merge data, are an
+// error rather than values of the result.
+type Removal struct {
+	// N is the number of elements removed, starting at the marker's index.
+	// It must be positive, so the zero Removal is rejected.
+	N int
+}
+
+// Remove removes the element at its index.
+var Remove = Removal{N: 1}
+
+// RemoveN removes n elements starting at its index.
+func RemoveN(n int) Removal {
+	return Removal{N: n}
+}
+
+func isRemoval(v any) bool {
+	_, ok := v.(Removal)
+	return ok
+}
+
+// markRemovals replaces the elements of out that removal markers in
+// mergeData point at with the removed sentinel, for dropRemoved to drop.
+// Only the first origLen elements, the ones of orig, can be removed.
+func (c *Context) markRemovals(path []string, out []any, origLen int, mergeData map[int]any) error {
+	for i, v := range mergeData {
+		r, ok := v.(Removal)
+		if !ok {
+			continue
+		}
+		if r.N <= 0 {
+			return fmt.Errorf("removal at index %d: N must be positive, got %d", i, r.N)
+		}
+		n := r.N
+		if i < 0 {
+			i += origLen
+		}
+		if i < 0 {
+			// the part before the first element removes nothing
+			if i+n <= 0 {
+				continue
+			}
+			n, i = n+i, 0
+		}
+		end := origLen
+		if n < origLen-i {
+			end = i + n
+		}
+		for j := i; j < end; j++ {
+			if out[j] == removed {
+				continue
+			}
+			p := append(path, strconv.Itoa(j))
+			if !c.removable(p, out[j]) {
+				if _, err := c.skip(p, out[j]); err != nil {
+					return err
+				}
+				continue
+			}
+			out[j] = removed
+		}
+	}
+	return nil
+}
+
+// applyRemovals returns orig without the elements removal markers point
+// at and merge data without the markers, for strategies taking sparse
+// merge data as a list of values.
+func (c *Context) applyRemovals(path []string, orig []any, mergeData map[int]any) ([]any, map[int]any, error) {
+	rest := make(map[int]any, len(mergeData))
+	for i, v := range mergeData {
+		if !isRemoval(v) {
+			rest[i] = v
+		}
+	}
+	if len(rest) == len(mergeData) {
+		return orig, mergeData, nil
+	}
+
+	out := make([]any, len(orig))
+	copy(out, orig)
+	if err := c.markRemovals(path, out, len(orig), mergeData); err != nil {
+		return nil, nil, err
+	}
+	return dropRemoved(out), rest, nil
+}
+
+// hasRemoval reports whether v holds a removal marker.
+func hasRemoval(v any) bool {
+	switch val := v.(type) {
+	case Removal:
+		return true
+	case map[string]any:
+		for _, elem := range val {
+			if hasRemoval(elem) {
+				return true
+			}
+		}
+	case map[int]any:
+		for _, elem := range val {
+			if hasRemoval(elem) {
+				return true
+			}
+		}
+	case []any:
+		for _, elem := range val {
+			if hasRemoval(elem) {
+				return true
+			}
+		}
+	default:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Map {
+			for iter := rv.MapRange(); iter.Next(); {
+				if hasRemoval(iter.Value().Interface()) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// dropRemovals fails for every removal marker left in v: sparse array
+// merges consume them, anywhere else they'd be stored as values. When
+// collecting errors, the markers are dropped instead.
+func (c *Context) dropRemovals(path []string, v any) (any, error) {
+	switch val := v.(type) {
+	case Removal:
+		_, err := c.fail(nil, fmt.Errorf("merge error at %v: removal marker outside a sparse array merge", formatPath(path)))
+		return removed, err
+	case map[string]any:
+		for k, elem := range val {
+			res, err := c.dropRemovals(append(path, k), elem)
+			if err != nil {
+				return nil, err
+			}
+			if res == removed {
+				delete(val, k)
+			} else {
+				val[k] = res
+			}
+		}
+	case map[int]any:
+		for k, elem := range val {
+			res, err := c.dropRemovals(append(path, strconv.Itoa(k)), elem)
+			if err != nil {
+				return nil, err
+			}
+			if res == removed {
+				delete(val, k)
+			} else {
+				val[k] = res
+			}
+		}
+	case []any:
+		n := len(val)
+		for i, elem := range val {
+			res, err := c.dropRemovals(append(path, strconv.Itoa(i)), elem)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = res
+		}
+		if val = dropRemoved(val); len(val) < n {
+			return val, nil
+		}
+	default:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Map {
+			break
+		}
+		for iter := rv.MapRange(); iter.Next(); {
+			elem := iter.Value().Interface()
+			if !hasRemoval(elem) {
+				continue
+			}
+			res, err := c.dropRemovals(append(path, pathSegment(iter.Key().Interface())), elem)
+			if err != nil {
+				return nil, err
+			}
+			if res == removed {
+				rv.SetMapIndex(iter.Key(), reflect.Value{})
+			} else if r := reflect.ValueOf(res); r.IsValid() && r.Type().AssignableTo(rv.Type().Elem()) {
+				rv.SetMapIndex(iter.Key(), r)
+			}
+		}
+	}
+	return v, nil
+}
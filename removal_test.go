@@ -0,0 +1,152 @@
+package merge_test
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestRemoval(t *testing.T) {
+	var cases []TestCase
+	for _, m := range []merge.Mode{merge.ModeFullReplace, merge.ModePartialReplace, merge.ModeInsert, merge.ModeUpdate, merge.ModeSet} {
+		cases = append(cases, TestCase{
+			Name:     "remove index/" + mustModeName(m),
+			Mode:     m,
+			Original: M("items", []any{"a", "b", "c", "d"}),
+			Merge:    M("items", map[int]any{3: merge.Remove, 1: merge.Remove}),
+			Expected: M("items", []any{"a", "c"}),
+		})
+	}
+
+	cases = append(cases, []TestCase{
+		{
+			Name:     "Removal after updates refers to orig",
+			Mode:     merge.ModeFullReplace,
+			Original: []any{"a", "b", "c", "d"},
+			Merge:    map[int]any{0: nil, 1: "B", 2: merge.Remove},
+			Options:  []merge.Option{merge.WithNullPolicy(merge.NullDelete)},
+			Expected: []any{"B", "d"},
+		},
+		{
+			Name:     "Remove range",
+			Mode:     merge.ModeUpdate,
+			Original: []any{1, 2, 3, 4, 5},
+			Merge:    map[int]any{1: merge.RemoveN(3)},
+			Expected: []any{1, 5},
+		},
+		{
+			Name:     "Negative index and range past the end",
+			Mode:     merge.ModeInsert,
+			Original: []any{1, 2, 3},
+			Merge:    map[int]any{-2: merge.RemoveN(10), 5: 4},
+			Expected: []any{1, 4},
+		},
+		{
+			Name:     "Append removes from orig",
+			Mode:     merge.ModeAppend,
+			Original: []any{1, 2, 3},
+			Merge:    map[int]any{0: merge.Remove, 1: 4},
+			Expected: []any{2, 3, 4},
+		},
+		{
+			Name:     "Prepend removes from orig",
+			Mode:     merge.ModePrepend,
+			Original: []any{1, 2, 3},
+			Merge:    map[int]any{2: merge.Remove, 0: 0},
+			Expected: []any{0, 1, 2},
+		},
+		{
+			Name:     "Splice replaces elements",
+			Mode:     merge.ModeSplice,
+			Original: []any{"a", "b", "c"},
+			Merge:    map[int]any{1: "B", -2: merge.Remove},
+			Expected: []any{"a", "B", "c"},
+		},
+		{
+			Name:     "Removal keeps excluded elements",
+			Mode:     merge.ModeUpdate,
+			Original: []any{M("name", "a", "password", "x"), M("name", "b")},
+			Merge:    map[int]any{0: merge.RemoveN(2)},
+			Options:  []merge.Option{merge.WithExclude("**.password")},
+			Expected: []any{M("name", "a", "password", "x")},
+		},
+		{
+			Name:      "Zero removal is rejected",
+			Mode:      merge.ModeInsert,
+			Original:  []any{"a", "b"},
+			Merge:     map[int]any{0: merge.Removal{}},
+			ShouldErr: true,
+			ErrMsg:    "N must be positive, got 0",
+		},
+		{
+			Name:      "Negative removal is rejected",
+			Mode:      merge.ModeDifference,
+			Original:  []any{"a", "b"},
+			Merge:     map[int]any{0: merge.RemoveN(-1)},
+			ShouldErr: true,
+			ErrMsg:    "N must be positive, got -1",
+		},
+		{
+			Name:     "Removal past the end doesn't overflow",
+			Mode:     merge.ModeUpdate,
+			Original: []any{1, 2, 3},
+			Merge:    map[int]any{1: merge.RemoveN(math.MaxInt)},
+			Expected: []any{1},
+		},
+		{
+			Name:     "Negative index before the start removes the overlap",
+			Mode:     merge.ModeUpdate,
+			Original: []any{1, 2, 3},
+			Merge:    map[int]any{-5: merge.RemoveN(3)},
+			Expected: []any{2, 3},
+		},
+		{
+			Name:      "Marker in an int map is rejected",
+			Mode:      merge.ModeFullReplace,
+			Original:  M("m", map[int]any{3: "x"}),
+			Merge:     M("m", map[int]any{3: merge.Remove}),
+			ShouldErr: true,
+			ErrMsg:    "removal marker outside a sparse array merge",
+		},
+		{
+			Name:      "Marker in array merge data is rejected",
+			Mode:      merge.ModeAppend,
+			Original:  M("a", []any{1}),
+			Merge:     M("a", []any{merge.Remove}),
+			ShouldErr: true,
+			ErrMsg:    "merge error at [a 1]: removal marker",
+		},
+		{
+			Name:      "Marker under a nil orig is rejected",
+			Mode:      merge.ModeInsert,
+			Original:  nil,
+			Merge:     M("a", map[int]any{0: merge.Remove}),
+			ShouldErr: true,
+			ErrMsg:    "removal marker outside a sparse array merge",
+		},
+	}...)
+
+	TableTest(t, cases)
+}
+
+func TestRemoval_CollectDropsLeakedMarkers(t *testing.T) {
+	res, err := merge.Data(merge.ModeInsert,
+		M("a", []any{1}),
+		M("a", map[int]any{0: merge.Remove}, "b", merge.Remove, "c", []any{merge.Remove, 2}),
+		merge.WithCollectErrors(),
+	)
+	if err == nil || strings.Count(err.Error(), "removal marker outside") != 2 {
+		t.Errorf("expected 2 removal marker errors, got %v", err)
+	}
+	if want := M("a", []any{}, "c", []any{2}); !reflect.DeepEqual(res, want) {
+		t.Errorf("Result mismatch:\nGot:      %s\nExpected: %s", toJSON(res), toJSON(want))
+	}
+}
+
+func mustModeName(m merge.Mode) string {
+	name, _ := merge.DefaultEngine().ModeName(m)
+	return name
+}
@@ -1,9 +1,63 @@
 package merge_test
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
 
 func TestReplacetMode_Maps(t *testing.T) {
 	cases := []TestCase{}
 
 	TableTest(t, cases)
 }
+
+func TestReplaceMode_Arrays(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "Replace truncates to merge data",
+			Mode:     merge.ModeFullReplace,
+			Original: []any{1, 2, 3},
+			Merge:    []any{4, 5},
+			Expected: []any{4, 5},
+		},
+		{
+			Name:     "Replace merges elements by index",
+			Mode:     merge.ModeFullReplace,
+			Original: []any{M("a", 1), M("b", 1), M("c", 1)},
+			Merge:    []any{M("a", 2)},
+			Expected: []any{M("a", 2)},
+		},
+		{
+			Name:     "Replace with empty array",
+			Mode:     merge.ModeFullReplace,
+			Original: M("tags", []any{"a", "b"}),
+			Merge:    M("tags", []any{}),
+			Expected: M("tags", []any{}),
+		},
+		{
+			Name:     "Partial replace keeps the tail",
+			Mode:     merge.ModePartialReplace,
+			Original: []any{1, 2, 3},
+			Merge:    []any{4, 5},
+			Expected: []any{4, 5, 3},
+		},
+		{
+			Name:     "Sparse replace keeps missing indexes",
+			Mode:     merge.ModeFullReplace,
+			Original: []any{1, 2, 3},
+			Merge:    map[int]any{1: 20},
+			Expected: []any{1, 20, 3},
+		},
+		{
+			Name:     "Truncation keeps excluded elements",
+			Mode:     merge.ModeFullReplace,
+			Original: []any{1, 2, 3, 4},
+			Merge:    []any{10},
+			Options:  []merge.Option{merge.WithExclude("2")},
+			Expected: []any{10, 2, 3},
+		},
+	}
+
+	TableTest(t, cases)
+}
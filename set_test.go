@@ -0,0 +1,73 @@
+package merge_test
+
+import (
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestSetMode(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "Set replaces maps",
+			Mode:     merge.ModeSet,
+			Original: M("a", 1, "b", M("x", 1, "y", 2)),
+			Merge:    M("b", M("z", 3)),
+			Expected: M("b", M("z", 3)),
+		},
+		{
+			Name:     "Set replaces arrays",
+			Mode:     merge.ModeSet,
+			Original: M("a", []any{1, 2, 3}),
+			Merge:    M("a", []any{M("x", 1)}),
+			Expected: M("a", []any{M("x", 1)}),
+		},
+		{
+			Name:     "Set replaces int maps",
+			Mode:     merge.ModeSet,
+			Original: map[int]any{1: "a", 2: M("x", 1)},
+			Merge:    map[int]any{2: M("y", 2)},
+			Expected: map[int]any{2: M("y", 2)},
+		},
+		{
+			Name:     "Set replaces keyed maps",
+			Mode:     merge.ModeSet,
+			Original: map[int64]any{1: "a", 2: "b"},
+			Merge:    map[int64]any{3: "c"},
+			Expected: map[int64]any{3: "c"},
+		},
+		{
+			Name:     "Set replaces containers of another kind",
+			Mode:     merge.ModeSet,
+			Original: M("a", M("x", 1), "b", []any{1}),
+			Merge:    M("a", []any{1}, "b", "off"),
+			Expected: M("a", []any{1}, "b", "off"),
+		},
+		{
+			Name:     "Set sparse arrays by index",
+			Mode:     merge.ModeSet,
+			Original: []any{M("x", 1), M("y", 2)},
+			Merge:    map[int]any{1: M("z", 3), 2: 4},
+			Expected: []any{M("x", 1), M("z", 3), 4},
+		},
+		{
+			Name:     "Set keeps excluded paths",
+			Mode:     merge.ModeSet,
+			Original: M("db", M("host", "a", "password", "secret"), "old", 1),
+			Merge:    M("db", M("host", "b")),
+			Options:  []merge.Option{merge.WithExclude("**.password")},
+			Expected: M("db", M("host", "b", "password", "secret")),
+		},
+		{
+			Name:      "Set fails on protected paths",
+			Mode:      merge.ModeSet,
+			Original:  M("security", M("tls", true), "db", 1),
+			Merge:     M("db", 2),
+			Options:   []merge.Option{merge.WithProtected("security")},
+			ShouldErr: true,
+			ErrMsg:    "removed security: path is protected",
+		},
+	}
+
+	TableTest(t, cases)
+}
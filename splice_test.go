@@ -0,0 +1,71 @@
+package merge_test
+
+import (
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestSpliceMode(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "Insert before index",
+			Mode:     merge.ModeSplice,
+			Original: []any{"a", "c"},
+			Merge:    map[int]any{1: "b"},
+			Expected: []any{"a", "b", "c"},
+		},
+		{
+			Name:     "Indexes refer to orig",
+			Mode:     merge.ModeSplice,
+			Original: []any{"a", "b", "c"},
+			Merge:    map[int]any{0: "x", 2: "y", 3: "z"},
+			Expected: []any{"x", "a", "b", "y", "c", "z"},
+		},
+		{
+			Name:     "Negative indexes count from the end",
+			Mode:     merge.ModeSplice,
+			Original: []any{"a", "b", "c"},
+			Merge:    map[int]any{-1: "y", -10: "x"},
+			Expected: []any{"x", "a", "b", "y", "c"},
+		},
+		{
+			Name:     "Past the end appends in index order",
+			Mode:     merge.ModeSplice,
+			Original: []any{"a"},
+			Merge:    map[int]any{9: "c", 5: "b"},
+			Expected: []any{"a", "b", "c"},
+		},
+		{
+			Name:     "Same position in index order",
+			Mode:     merge.ModeSplice,
+			Original: []any{"a", "b"},
+			Merge:    map[int]any{-1: "x", 1: "y"},
+			Expected: []any{"a", "x", "y", "b"},
+		},
+		{
+			Name:     "Splice nested and insert maps",
+			Mode:     merge.ModeSplice,
+			Original: M("chain", []any{"auth", "handler"}, "name", "x"),
+			Merge:    M("chain", map[int]any{-1: "ratelimit"}, "name", "y"),
+			Expected: M("chain", []any{"auth", "ratelimit", "handler"}, "name", "x"),
+		},
+		{
+			Name:     "Arrays append",
+			Mode:     merge.ModeSplice,
+			Original: []any{1},
+			Merge:    []any{2},
+			Expected: []any{1, 2},
+		},
+		{
+			Name:     "Inserted values are pruned",
+			Mode:     merge.ModeSplice,
+			Original: []any{M("name", "a", "password", "x")},
+			Merge:    map[int]any{0: M("name", "b", "password", "y")},
+			Options:  []merge.Option{merge.WithExclude("**.password")},
+			Expected: []any{M("name", "b"), M("name", "a", "password", "x")},
+		},
+	}
+
+	TableTest(t, cases)
+}
@@ -0,0 +1,110 @@
+package merge
+
+import (
+	"slices"
+	"strings"
+)
+
+// PrimitiveRule merges the primitives at paths matching Pattern with
+// Strategy instead of the mode, see WithPrimitiveStrategy.
+type PrimitiveRule struct {
+	Pattern  string
+	Strategy PrimitiveStrategy
+}
+
+// WithPrimitiveStrategy merges primitives at paths matching pattern with s
+// whatever the mode, e.g. WithPrimitiveStrategy("env.PATH", StringJoinUnique(":")).
+// See WithInclude for the pattern syntax, patterns match whole paths. When
+// several patterns match, the last one given wins. Like WithArrayStrategy,
+// it applies inside the engine's middlewares.
+func WithPrimitiveStrategy(pattern string, s PrimitiveStrategy) Option {
+	return func(o *Options) {
+		o.Primitives = append(o.Primitives, PrimitiveRule{Pattern: pattern, Strategy: s})
+	}
+}
+
+// StringConcat joins orig and merge data strings with sep, dropping the
+// separator when either of them is empty.
+func StringConcat(sep string) PrimitiveStrategy {
+	return stringStrategy(func(orig, data string) string {
+		return joinNonEmpty(sep, orig, data)
+	})
+}
+
+// StringJoinUnique treats strings as sep separated lists, like PATH, and
+// appends the parts of merge data orig doesn't have yet.
+func StringJoinUnique(sep string) PrimitiveStrategy {
+	return stringStrategy(func(orig, data string) string {
+		var parts []string
+		if orig != "" {
+			parts = strings.Split(orig, sep)
+		}
+		for _, p := range strings.Split(data, sep) {
+			if p != "" && !slices.Contains(parts, p) {
+				parts = append(parts, p)
+			}
+		}
+		return strings.Join(parts, sep)
+	})
+}
+
+// StringLongest keeps the longer string, orig on ties.
+func StringLongest() PrimitiveStrategy {
+	return stringStrategy(func(orig, data string) string {
+		if len(data) > len(orig) {
+			return data
+		}
+		return orig
+	})
+}
+
+// StringTemplate combines strings by expanding tmpl, where {orig} and
+// {new} stand for orig and merge data: "{orig} -Xmx{new}" for JVM flags,
+// "{new}:{orig}" to put a PATH entry first. When either string is empty
+// the other one is taken as is.
+func StringTemplate(tmpl string) PrimitiveStrategy {
+	return stringStrategy(func(orig, data string) string {
+		if orig == "" || data == "" {
+			return joinNonEmpty("", orig, data)
+		}
+		return strings.NewReplacer("{orig}", orig, "{new}", data).Replace(tmpl)
+	})
+}
+
+// stringStrategy applies combine to two strings. A nil orig takes merge
+// data, nil merge data keeps orig and other values are replaced by merge
+// data.
+func stringStrategy(combine func(orig, data string) string) PrimitiveStrategy {
+	return func(_ *Context, _ Merger, _ []string, orig, mergeData any) (any, error) {
+		if mergeData == nil {
+			return orig, nil
+		}
+		o, ok := orig.(string)
+		d, dataOk := mergeData.(string)
+		if !ok || !dataOk {
+			return mergeData, nil
+		}
+		return combine(o, d), nil
+	}
+}
+
+// primitiveStrategy returns the strategy set for path with
+// WithPrimitiveStrategy, nil when there's none.
+func (c *Context) primitiveStrategy(path []string) PrimitiveStrategy {
+	for i := len(c.opts.Primitives) - 1; i >= 0; i-- {
+		if matchPattern(c.primitives[i], path) {
+			return c.opts.Primitives[i].Strategy
+		}
+	}
+	return nil
+}
+
+func joinNonEmpty(sep string, a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	}
+	return a + sep + b
+}
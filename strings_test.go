@@ -0,0 +1,81 @@
+package merge_test
+
+import (
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestStringStrategies(t *testing.T) {
+	cases := []TestCase{
+		{
+			Name:     "Concat with separator",
+			Mode:     merge.ModeFullReplace,
+			Original: M("opts", "-Xms1g", "name", "a"),
+			Merge:    M("opts", "-Xmx2g", "name", "b"),
+			Options:  []merge.Option{merge.WithPrimitiveStrategy("opts", merge.StringConcat(" "))},
+			Expected: M("opts", "-Xms1g -Xmx2g", "name", "b"),
+		},
+		{
+			Name:     "Concat empty orig whatever the mode",
+			Mode:     merge.ModeUpdate,
+			Original: M("opts", ""),
+			Merge:    M("opts", "-ea"),
+			Options:  []merge.Option{merge.WithPrimitiveStrategy("opts", merge.StringConcat(" "))},
+			Expected: M("opts", "-ea"),
+		},
+		{
+			Name:     "Join unique PATH-like",
+			Mode:     merge.ModeFullReplace,
+			Original: M("env", M("PATH", "/usr/bin:/bin")),
+			Merge:    M("env", M("PATH", "/bin:/opt/bin")),
+			Options:  []merge.Option{merge.WithPrimitiveStrategy("env.PATH", merge.StringJoinUnique(":"))},
+			Expected: M("env", M("PATH", "/usr/bin:/bin:/opt/bin")),
+		},
+		{
+			Name:     "Keep longest",
+			Mode:     merge.ModeFullReplace,
+			Original: M("a", "short", "b", "longer one"),
+			Merge:    M("a", "longer", "b", "tiny"),
+			Options:  []merge.Option{merge.WithPrimitiveStrategy("*", merge.StringLongest())},
+			Expected: M("a", "longer", "b", "longer one"),
+		},
+		{
+			Name:     "Template",
+			Mode:     merge.ModeFullReplace,
+			Original: M("path", "/usr/bin"),
+			Merge:    M("path", "/opt/bin"),
+			Options:  []merge.Option{merge.WithPrimitiveStrategy("path", merge.StringTemplate("{new}:{orig}"))},
+			Expected: M("path", "/opt/bin:/usr/bin"),
+		},
+		{
+			Name:     "Non strings are replaced",
+			Mode:     merge.ModeInsert,
+			Original: M("a", 1),
+			Merge:    M("a", "x"),
+			Options:  []merge.Option{merge.WithPrimitiveStrategy("a", merge.StringConcat(","))},
+			Expected: M("a", "x"),
+		},
+		{
+			Name:     "Last matching rule wins",
+			Mode:     merge.ModeInsert,
+			Original: M("tags", M("a", "x", "b", "y")),
+			Merge:    M("tags", M("a", "z", "b", "z")),
+			Options: []merge.Option{
+				merge.WithPrimitiveStrategy("tags.*", merge.StringConcat(",")),
+				merge.WithPrimitiveStrategy("tags.b", merge.StringConcat(";")),
+			},
+			Expected: M("tags", M("a", "x,z", "b", "y;z")),
+		},
+		{
+			Name:     "Array elements",
+			Mode:     merge.ModeFullReplace,
+			Original: M("args", []any{"a", "b"}),
+			Merge:    M("args", []any{"c"}),
+			Options:  []merge.Option{merge.WithPrimitiveStrategy("args.*", merge.StringConcat("+"))},
+			Expected: M("args", []any{"a+c"}),
+		},
+	}
+
+	TableTest(t, cases)
+}
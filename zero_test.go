@@ -0,0 +1,73 @@
+package merge_test
+
+import (
+	"testing"
+
+	"github.com/4nd3r5on/go-merge"
+)
+
+func TestZeroPolicy(t *testing.T) {
+	nilOnly := []merge.Option{merge.WithZeroPolicy(merge.ZeroNil)}
+	present := []merge.Option{merge.WithZeroPolicy(merge.ZeroPresent)}
+
+	cases := []TestCase{
+		{
+			Name:     "default insert overwrites explicit falsy values",
+			Mode:     merge.ModeInsert,
+			Original: M("enabled", false, "retries", 0, "name", ""),
+			Merge:    M("enabled", true, "retries", 3, "name", "x"),
+			Expected: M("enabled", true, "retries", 3, "name", "x"),
+		},
+		{
+			Name:     "nil-only insert keeps explicit falsy values",
+			Mode:     merge.ModeInsert,
+			Original: M("enabled", false, "retries", 0, "name", "", "unset", nil),
+			Merge:    M("enabled", true, "retries", 3, "name", "x", "unset", "y"),
+			Options:  nilOnly,
+			Expected: M("enabled", false, "retries", 0, "name", "", "unset", "y"),
+		},
+		{
+			Name:     "present insert keeps explicit nil",
+			Mode:     merge.ModeInsert,
+			Original: M("unset", nil),
+			Merge:    M("unset", "y", "new", "z"),
+			Options:  present,
+			Expected: M("unset", nil, "new", "z"),
+		},
+		{
+			Name:     "default update skips zero fields",
+			Mode:     merge.ModeUpdate,
+			Original: M("retries", 0, "name", "a"),
+			Merge:    M("retries", 3, "name", "b"),
+			Expected: M("retries", 0, "name", "b"),
+		},
+		{
+			Name:     "nil-only update changes zero fields",
+			Mode:     merge.ModeUpdate,
+			Original: M("retries", 0, "enabled", false, "unset", nil),
+			Merge:    M("retries", 3, "enabled", true, "unset", "x"),
+			Options:  nilOnly,
+			Expected: M("retries", 3, "enabled", true, "unset", nil),
+		},
+		{
+			Name:     "present update changes nil fields",
+			Mode:     merge.ModeUpdate,
+			Original: M("unset", nil),
+			Merge:    M("unset", "x", "new", "y"),
+			Options:  present,
+			Expected: M("unset", "x"),
+		},
+		{
+			Name:     "custom predicate",
+			Mode:     merge.ModeInsert,
+			Original: M("host", "changeme", "port", 0),
+			Merge:    M("host", "db", "port", 5432),
+			Options: []merge.Option{merge.WithZeroPolicy(func(v any) bool {
+				return v == nil || v == "changeme"
+			})},
+			Expected: M("host", "db", "port", 0),
+		},
+	}
+
+	TableTest(t, cases)
+}